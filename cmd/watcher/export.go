@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/itohio/CoinWatcher/pkg/app"
+	"github.com/itohio/CoinWatcher/pkg/history"
+)
+
+// export writes recorded quote history to a file or stdout.
+func export(args []string) error {
+	var (
+		opts               history.ExportOptions
+		symbols, from, to  string
+		timezone, out, dir string
+	)
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: watcher export [flags]")
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&symbols, "symbols", "", "comma separated symbols, all recorded symbols if empty")
+	flags.StringVar(&opts.Currency, "currency", "USD", "currency of exported quotes")
+	flags.StringVar(&from, "from", "", "export quotes since YYYY-MM-DD [hh:mm]")
+	flags.StringVar(&to, "to", "", "export quotes until YYYY-MM-DD [hh:mm]")
+	flags.StringVar(&timezone, "tz", "Local", "time zone of exported times and of -from and -to")
+	flags.StringVar(&opts.Format, "format", "", "csv or jsonl, guessed from -o by default")
+	flags.StringVar(&out, "o", "", "output file, stdout if empty")
+	flags.StringVar(&dir, "history", "", "history directory, the one recorded by the watcher if empty")
+	flags.Parse(args)
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return err
+	}
+	opts.Location = loc
+	if from != "" {
+		if opts.From, err = history.ParseTime(from, loc); err != nil {
+			return err
+		}
+	}
+	if to != "" {
+		if opts.To, err = history.ParseTime(to, loc); err != nil {
+			return err
+		}
+	}
+	for _, s := range strings.Split(symbols, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			opts.Symbols = append(opts.Symbols, strings.ToUpper(s))
+		}
+	}
+	if opts.Format == "" {
+		opts.Format = history.FormatOf(out)
+	}
+
+	if dir == "" {
+		dir = app.HistoryDir()
+	}
+	store, err := history.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	n, err := store.Export(w, opts)
+	if err != nil {
+		return err
+	}
+	if out != "" {
+		fmt.Fprintf(os.Stderr, "Exported %d quotes to %s\n", n, out)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/itohio/CoinWatcher/pkg/app"
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+	"github.com/itohio/CoinWatcher/pkg/history"
+)
+
+// importCSV backfills recorded quote history from CSV files of historical prices.
+func importCSV(args []string) error {
+	var (
+		opts                  history.ImportOptions
+		symbol, timezone, dir string
+		provider              string
+		id                    int
+		interval              time.Duration
+	)
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: watcher import -symbol SYMBOL [flags] file.csv...")
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&symbol, "symbol", "", "symbol of imported prices")
+	flags.StringVar(&provider, "provider", "", "provider that lists the coin, the one recorded for symbol if empty")
+	flags.IntVar(&id, "id", 0, "id of the coin at provider")
+	flags.StringVar(&opts.Currency, "currency", "USD", "currency of imported prices")
+	flags.StringVar(&timezone, "tz", "UTC", "time zone of times without one")
+	flags.DurationVar(&interval, "interval", 0, "interval of imported rows, detected if zero")
+	flags.StringVar(&opts.Columns.Time, "date-col", "", "date column, detected if empty")
+	flags.StringVar(&opts.Columns.Open, "open-col", "", "open price column, detected if empty")
+	flags.StringVar(&opts.Columns.High, "high-col", "", "high price column, detected if empty")
+	flags.StringVar(&opts.Columns.Low, "low-col", "", "low price column, detected if empty")
+	flags.StringVar(&opts.Columns.Close, "close-col", "", "close price column, detected if empty")
+	flags.StringVar(&opts.Columns.Volume, "volume-col", "", "volume column, detected if empty")
+	flags.StringVar(&opts.Columns.MarketCap, "marketcap-col", "", "market cap column, detected if empty")
+	flags.StringVar(&dir, "history", "", "history directory, the one recorded by the watcher if empty")
+	flags.Parse(args)
+
+	if symbol == "" || flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("symbol and files are required")
+	}
+	opts.Symbol = crypto.Symbol{Symbol: strings.ToUpper(symbol), Provider: provider, Id: id}
+	opts.Currency = strings.ToUpper(opts.Currency)
+	opts.Interval = interval
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return err
+	}
+	opts.Location = loc
+
+	if dir == "" {
+		dir = app.HistoryDir()
+	}
+	store, err := history.Open(dir)
+	if err != nil {
+		return err
+	}
+	if err := store.Lock(); errors.Is(err, history.ErrLocked) {
+		return fmt.Errorf("%v, quit the watcher before importing", err)
+	} else if err != nil {
+		return err
+	}
+	defer store.Close()
+	if opts.Symbol.Provider == "" {
+		if opts.Symbol, err = recordedCoin(store, opts.Symbol.Symbol, opts.Currency); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range flags.Args() {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		result, err := store.Import(f, opts)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		fmt.Fprintf(os.Stderr, "%s: imported %d, duplicates %d, rejected %d\n", file, result.Imported, result.Duplicates, len(result.Rejected))
+		for _, r := range result.Rejected {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", file, r)
+		}
+	}
+	return nil
+}
+
+// recordedCoin returns the coin recorded with symbol in currency. Symbols of several recorded coins are ambiguous.
+func recordedCoin(store *history.Store, symbol, currency string) (crypto.Symbol, error) {
+	series, err := store.Series()
+	if err != nil {
+		return crypto.Symbol{}, err
+	}
+	var coins []string
+	ret := crypto.Symbol{Symbol: symbol}
+	for _, sr := range series {
+		if sr.Symbol != symbol || sr.Currency != currency || sr.Provider == "" {
+			continue
+		}
+		ret.Provider, ret.Id = sr.Provider, sr.Id
+		coins = append(coins, fmt.Sprintf("-provider %s -id %d", sr.Provider, sr.Id))
+	}
+	if len(coins) > 1 {
+		return crypto.Symbol{}, fmt.Errorf("%s is recorded for several coins, choose one of: %s", symbol, strings.Join(coins, ", "))
+	}
+	return ret, nil
+}
@@ -1,10 +1,35 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+
 	"github.com/itohio/CoinWatcher/pkg/app"
 )
 
 func main() {
-	watcher := app.New("Coin Watcher")
+	if len(os.Args) > 1 {
+		commands := map[string]func([]string) error{
+			"export": export,
+			"import": importCSV,
+		}
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	var options app.Options
+	flag.StringVar(&options.Record, "record", "", "record provider results to a fixture file")
+	flag.StringVar(&options.Replay, "replay", "", "replay provider results from a fixture file instead of fetching them")
+	flag.Float64Var(&options.ReplaySpeed, "speed", 1, "replay speed, 0 serves the latest recorded results")
+	flag.StringVar(&options.Rates, "rates", "", "URL or file to read ECB exchange rates from")
+	flag.Parse()
+
+	watcher := app.New("Coin Watcher", options)
 	watcher.Run()
 }
@@ -4,8 +4,10 @@ go 1.17
 
 require (
 	fyne.io/fyne/v2 v2.1.2
+	github.com/gobwas/ws v1.1.0
 	github.com/hexoul/go-coinmarketcap v1.3.2
 	github.com/rs/zerolog v1.26.1
+	golang.org/x/sys v0.0.0-20211124211545-fe61309f8881
 )
 
 require (
@@ -19,7 +21,6 @@ require (
 	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20211024062804-40e447a793be // indirect
 	github.com/gobwas/httphead v0.1.0 // indirect
 	github.com/gobwas/pool v0.2.1 // indirect
-	github.com/gobwas/ws v1.1.0 // indirect
 	github.com/godbus/dbus/v5 v5.0.4 // indirect
 	github.com/goki/freetype v0.0.0-20181231101311-fa8a33aabaff // indirect
 	github.com/josharian/intern v1.0.0 // indirect
@@ -31,7 +32,6 @@ require (
 	github.com/yuin/goldmark v1.4.0 // indirect
 	golang.org/x/image v0.0.0-20200430140353-33d19683fad8 // indirect
 	golang.org/x/net v0.0.0-20210805182204-aaa1db679c0d // indirect
-	golang.org/x/sys v0.0.0-20211124211545-fe61309f8881 // indirect
 	golang.org/x/text v0.3.6 // indirect
 	gopkg.in/yaml.v2 v2.2.8 // indirect
 )
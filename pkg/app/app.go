@@ -1,10 +1,13 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"image"
 	"image/png"
+	"io"
 	"path"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,19 +18,71 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/itohio/CoinWatcher/pkg/crypto"
+	"github.com/itohio/CoinWatcher/pkg/history"
 	"github.com/itohio/CoinWatcher/pkg/logger"
 )
 
+// Options configure how the price feed is created.
+type Options struct {
+	// Record appends every provider result to this fixture file
+	Record string
+	// Replay serves results from this fixture file instead of a live provider
+	Replay string
+	// ReplaySpeed accelerates the replay, zero serves the latest recorded results
+	ReplaySpeed float64
+	// Rates is the URL or file to read ECB exchange rates from
+	Rates string
+}
+
+const appID = "itohio.coin.watcher"
+
 type App struct {
 	sync.Mutex
-	app    fyne.App
-	window fyne.Window
+	app     fyne.App
+	window  fyne.Window
+	options Options
+
+	// ctx is cancelled when the app closes, feedCtx when the feed is recreated
+	// and callCtx when settings change
+	ctx            context.Context
+	cancel         context.CancelFunc
+	feedCtx        context.Context
+	feedCancel     context.CancelFunc
+	callCtx        context.Context
+	callCancel     context.CancelFunc
+	requestTimeout time.Duration
+	http           crypto.HTTPConfig
+	dex            crypto.DEXConfig
+	staleAfter     time.Duration
+
+	currency      string
+	secondary     string
+	interval      time.Duration
+	lastUpdated   time.Time
+	feed          crypto.Crypto
+	feedGen       int
+	recorder      io.Closer
+	rates         crypto.RateSource
+	listingTTL    time.Duration
+	forceListings bool
+	provider      string
+	maxDeviation  float64
+	apiKey        string
+	streaming     bool
+	streamCancel  context.CancelFunc
+	history       *history.Store
+	retention     history.Retention
+	portfolio     Portfolio
+
+	creditsLock      sync.Mutex
+	credits          Credits
+	creditBudget     int
+	adaptiveInterval bool
+	refreshCredits   int
 
-	currency    string
-	interval    time.Duration
-	lastUpdated time.Time
-	feed        crypto.Crypto
-	apiKey      string
+	sparkLock   sync.Mutex
+	sparkWindow time.Duration
+	sparks      map[history.Series]sparkline
 
 	coinData       []interface{}
 	data           binding.ExternalUntypedList
@@ -35,74 +90,148 @@ type App struct {
 
 	imageCache map[string]image.Image
 
-	currencyWidget *widget.Select
-	pbWidget       *widget.ProgressBar
-	timeout        binding.Float
+	listWidget      *widget.List
+	currencyWidget  *widget.Select
+	secondaryWidget *widget.Select
+	pbWidget        *widget.ProgressBar
+	statusWidget    *widget.Label
+	creditsWidget   *widget.Label
+	timeout         binding.Float
+
+	// statusLock guards failoverEvent, failover reports it from feed calls
+	statusLock    sync.Mutex
+	failoverEvent string
 }
 
 var _ crypto.Cache = &App{}
 
-func New(name string) *App {
-	a := app.NewWithID("itohio.coin.watcher")
+func New(name string, options Options) *App {
+	a := app.NewWithID(appID)
 	w := a.NewWindow(name)
 	w.Resize(fyne.NewSize(350, 600))
 
 	ret := &App{
 		app:        a,
 		window:     w,
+		options:    options,
+		rates:      crypto.NewECB(options.Rates),
 		imageCache: make(map[string]image.Image),
 	}
+	ret.ctx, ret.cancel = context.WithCancel(context.Background())
+	ret.cancelCallsLocked(true)
+	w.SetOnClosed(ret.cancel)
 
 	ret.loadSettings()
+	ret.loadCredits()
+	ret.openHistory()
 
 	ret.data = binding.BindUntypedList(&ret.coinData)
 	ret.timeout = binding.NewFloat()
 
 	ret.loadCoins()
+	ret.loadPortfolio()
 
 	list := ret.makeList()
+	ret.listWidget = list
 	menu := ret.makeMenu()
 
 	ret.pbWidget = widget.NewProgressBarWithData(ret.timeout)
 	ret.pbWidget.Min = 0
 	ret.pbWidget.Max = 100
 	ret.pbWidget.TextFormatter = func() string {
-		return fmt.Sprint("ETA ", ((ret.interval-time.Since(ret.lastUpdated))/time.Minute)*time.Minute)
+		return fmt.Sprint("ETA ", ((ret.refreshInterval()-time.Since(ret.lastUpdated))/time.Minute)*time.Minute)
 	}
 	btnUpdate := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), func() {
+		if ret.currentFeed() == nil {
+			ret.connect()
+			return
+		}
 		ret.updateQuotes()
 	})
+	ret.statusWidget = widget.NewLabel("")
+	ret.statusWidget.Wrapping = fyne.TextTruncate
+	ret.creditsWidget = widget.NewLabel("")
+	ret.updateCredits()
 
 	w.SetContent(
 		container.NewBorder(
 			menu,
-			container.NewBorder(nil, nil, nil, btnUpdate, ret.pbWidget),
+			container.NewVBox(
+				container.NewBorder(nil, nil, nil, ret.creditsWidget, ret.statusWidget),
+				container.NewBorder(nil, nil, nil, btnUpdate, ret.pbWidget),
+			),
 			nil, nil,
 			list,
 		),
 	)
 
+	ret.connect()
+
 	return ret
 }
 
 func (a *App) Run() {
 	go func() {
 		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
 		for {
 			select {
+			case <-a.ctx.Done():
+				return
 			case <-ticker.C:
-				d := time.Since(a.lastUpdated).Seconds() / a.interval.Seconds()
+				d := time.Since(a.lastUpdated).Seconds() / a.refreshInterval().Seconds()
 				if d >= 1 {
 					a.updateQuotes()
 					d = 1
 				}
 
 				a.timeout.Set(d * 100)
+				a.listWidget.Refresh()
 			}
 		}
 	}()
 	a.window.Show()
 	a.app.Run()
+	a.closeRecorder()
+	if a.history != nil {
+		a.history.Close()
+	}
+}
+
+// setStatus shows the current provider, the last failover event and msg in the status area.
+func (a *App) setStatus(msg string) {
+	if a.statusWidget == nil {
+		return
+	}
+	status := []string{"Disconnected"}
+	if feed := a.currentFeed(); feed != nil {
+		status[0] = feed.Name()
+	}
+	a.statusLock.Lock()
+	event := a.failoverEvent
+	a.statusLock.Unlock()
+	if event != "" {
+		status = append(status, event)
+	}
+	if msg != "" {
+		status = append(status, msg)
+	}
+	a.statusWidget.SetText(strings.Join(status, " | "))
+}
+
+func (a *App) onFailover(from, to crypto.Crypto, err error) {
+	if err != nil {
+		a.setFailoverEvent(fmt.Sprintf("%s %s failed: %v", time.Now().Format("15:04"), from.Name(), err))
+	} else {
+		a.setFailoverEvent(fmt.Sprintf("%s back to %s", time.Now().Format("15:04"), to.Name()))
+	}
+	a.setStatus("")
+}
+
+func (a *App) setFailoverEvent(event string) {
+	a.statusLock.Lock()
+	a.failoverEvent = event
+	a.statusLock.Unlock()
 }
 
 func (a *App) LoadImage(url string) (image.Image, error) {
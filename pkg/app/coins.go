@@ -2,25 +2,30 @@ package app
 
 import (
 	"encoding/json"
+	"fmt"
 
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
 	"github.com/itohio/CoinWatcher/pkg/crypto"
 	"github.com/itohio/CoinWatcher/pkg/widgets/coin"
 )
 
 type Coin struct {
-	Symbol string `json:"symbol"`
-	Name   string `json:"name"`
+	Id       int    `json:"id,omitempty"`
+	Provider string `json:"provider,omitempty"`
+	Symbol   string `json:"symbol"`
+	Name     string `json:"name"`
 }
 
 type Coins struct {
 	Coins []Coin `json:"coins"`
 }
 
-func (a *App) defaultCoins() {
-	if a.feed == nil {
+func (a *App) defaultCoins(feed crypto.Crypto) {
+	if feed == nil {
 		return
 	}
-	for _, ss := range a.feed.GetSymbols() {
+	for _, ss := range feed.GetSymbols() {
 		switch ss.Symbol {
 		case "BTC":
 			fallthrough
@@ -37,7 +42,7 @@ func (a *App) defaultCoins() {
 func (a *App) loadCoins() {
 	reader, err := a.reader("coins.json")
 	if err != nil {
-		a.defaultCoins()
+		a.defaultCoins(a.currentFeed())
 		return
 	}
 	defer reader.Close()
@@ -46,7 +51,7 @@ func (a *App) loadCoins() {
 
 	err = json.NewDecoder(reader).Decode(&coins)
 	if err != nil {
-		a.defaultCoins()
+		a.defaultCoins(a.currentFeed())
 		return
 	}
 
@@ -57,8 +62,10 @@ func (a *App) loadCoins() {
 
 	for _, c := range coins.Coins {
 		a.addSymbol(crypto.Symbol{
-			Name:   c.Name,
-			Symbol: c.Symbol,
+			Id:       c.Id,
+			Provider: c.Provider,
+			Name:     c.Name,
+			Symbol:   c.Symbol,
 		})
 	}
 }
@@ -80,8 +87,10 @@ func (a *App) saveCoins() {
 	for i, c := range a.coinData {
 		if cn, ok := c.(*coin.CoinData); ok {
 			coins.Coins[i] = Coin{
-				Symbol: cn.Symbol.Symbol,
-				Name:   cn.Symbol.Name,
+				Id:       cn.Symbol.Id,
+				Provider: cn.Symbol.Provider,
+				Symbol:   cn.Symbol.Symbol,
+				Name:     cn.Symbol.Name,
 			}
 		}
 	}
@@ -95,3 +104,117 @@ func (a *App) saveCoins() {
 
 	}
 }
+
+// resolveCoins rebinds the coin list to symbols of feed. Coins are matched by provider id,
+// then by ticker. Tickers listed more than once are told apart by name or by asking the user.
+func (a *App) resolveCoins(feed crypto.Crypto) {
+	symbols := feed.GetSymbols()
+
+	a.Lock()
+	var (
+		changed   bool
+		ambiguous []int
+	)
+	for i, cd := range a.coinData {
+		cn, ok := cd.(*coin.CoinData)
+		if !ok {
+			continue
+		}
+		candidates := matchSymbols(symbols, cn.Symbol)
+		if len(candidates) != 1 {
+			if len(candidates) > 1 {
+				ambiguous = append(ambiguous, i)
+			}
+			continue
+		}
+		if s := candidates[0]; s.Id != cn.Symbol.Id || s.Provider != cn.Symbol.Provider {
+			updated := cn.WithCurrencies(cn.Currency, cn.Secondary)
+			updated.Symbol = s
+			a.coinData[i] = updated
+			changed = true
+		}
+	}
+	a.Unlock()
+
+	if changed {
+		a.data.Reload()
+		a.saveCoins()
+	}
+	for _, i := range ambiguous {
+		a.askCoin(i, symbols)
+	}
+}
+
+// matchSymbols returns feed symbols that may denote s.
+func matchSymbols(symbols []crypto.Symbol, s crypto.Symbol) []crypto.Symbol {
+	var byTicker, byName []crypto.Symbol
+	for _, sym := range symbols {
+		if s.Id != 0 && sym.Provider == s.Provider && sym.Id == s.Id {
+			return []crypto.Symbol{sym}
+		}
+		if sym.Symbol != s.Symbol {
+			continue
+		}
+		byTicker = append(byTicker, sym)
+		if sym.Name == s.Name {
+			byName = append(byName, sym)
+		}
+	}
+	if len(byTicker) > 1 && len(byName) == 1 {
+		return byName
+	}
+	return byTicker
+}
+
+// askCoin lets the user pick which of the coins sharing a ticker the i-th coin is.
+func (a *App) askCoin(i int, symbols []crypto.Symbol) {
+	a.Lock()
+	if i >= len(a.coinData) {
+		a.Unlock()
+		return
+	}
+	cn, ok := a.coinData[i].(*coin.CoinData)
+	a.Unlock()
+	if !ok {
+		return
+	}
+
+	candidates := matchSymbols(symbols, cn.Symbol)
+	options := make([]string, len(candidates))
+	for j, s := range candidates {
+		options[j] = fmt.Sprintf("%s #%d", symbolOption(s), s.Id)
+	}
+	choice := widget.NewSelect(options, nil)
+	choice.SetSelectedIndex(0)
+
+	dialog.ShowForm(
+		fmt.Sprintf("Which %s?", cn.Symbol.Symbol),
+		"OK",
+		"Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Coin", choice),
+		},
+		func(b bool) {
+			if !b || choice.SelectedIndex() < 0 {
+				return
+			}
+			a.Lock()
+			if i >= len(a.coinData) {
+				a.Unlock()
+				return
+			}
+			if cur, ok := a.coinData[i].(*coin.CoinData); !ok || !cur.Symbol.Is(cn.Symbol) {
+				a.Unlock()
+				return
+			}
+			a.coinData[i] = coin.NewSymbol(candidates[choice.SelectedIndex()], a.currency, a.secondary)
+			a.Unlock()
+
+			a.data.Reload()
+			a.saveCoins()
+			a.updateQuotes()
+			a.restartStream()
+		},
+		a.window,
+	)
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"image"
+	"reflect"
+	"testing"
+
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/test"
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+	"github.com/itohio/CoinWatcher/pkg/widgets/coin"
+)
+
+// newTestApp returns an app without a window that stores its files in a temporary directory.
+func newTestApp(t *testing.T) *App {
+	t.Helper()
+	t.Setenv("TMPDIR", t.TempDir())
+	a := &App{
+		app:        test.NewApp(),
+		imageCache: make(map[string]image.Image),
+	}
+	a.data = binding.BindUntypedList(&a.coinData)
+	return a
+}
+
+// testFeed lists symbols, other calls are not expected.
+type testFeed struct {
+	crypto.Crypto
+	symbols []crypto.Symbol
+}
+
+func (f testFeed) GetSymbols() []crypto.Symbol { return f.symbols }
+
+var (
+	btc     = crypto.Symbol{Symbol: "BTC", Name: "Bitcoin", Provider: "coingecko", Id: 1}
+	eth     = crypto.Symbol{Symbol: "ETH", Name: "Ethereum", Provider: "coingecko", Id: 2}
+	ethWorm = crypto.Symbol{Symbol: "ETH", Name: "Ethereum (Wormhole)", Provider: "coingecko", Id: 3}
+	cmcBTC  = crypto.Symbol{Symbol: "BTC", Name: "Bitcoin", Provider: "CoinMarketCap", Id: 1}
+)
+
+func TestMatchSymbols(t *testing.T) {
+	symbols := []crypto.Symbol{btc, eth, ethWorm}
+	for _, tc := range []struct {
+		name string
+		s    crypto.Symbol
+		want []crypto.Symbol
+	}{
+		{"by id", crypto.Symbol{Symbol: "OLD", Provider: "coingecko", Id: 3}, []crypto.Symbol{ethWorm}},
+		{"by ticker", crypto.Symbol{Symbol: "BTC"}, []crypto.Symbol{btc}},
+		{"other provider", cmcBTC, []crypto.Symbol{btc}},
+		{"by name", crypto.Symbol{Symbol: "ETH", Name: "Ethereum"}, []crypto.Symbol{eth}},
+		{"ambiguous", crypto.Symbol{Symbol: "ETH"}, []crypto.Symbol{eth, ethWorm}},
+		{"unknown", crypto.Symbol{Symbol: "XYZ"}, nil},
+	} {
+		if got := matchSymbols(symbols, tc.s); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestResolveCoins(t *testing.T) {
+	a := newTestApp(t)
+	unknown := crypto.Symbol{Symbol: "XYZ"}
+	for _, s := range []crypto.Symbol{cmcBTC, {Symbol: "ETH", Name: "Ethereum"}, unknown} {
+		a.coinData = append(a.coinData, coin.NewSymbol(s, "USD", "EUR"))
+	}
+	a.data.Reload()
+
+	a.resolveCoins(testFeed{symbols: []crypto.Symbol{btc, eth, ethWorm}})
+
+	want := []crypto.Symbol{btc, eth, unknown}
+	for i, cd := range a.coinData {
+		cn := cd.(*coin.CoinData)
+		if cn.Symbol != want[i] || cn.Currency != "USD" || cn.Secondary != "EUR" {
+			t.Errorf("coin %d is %+v in %s/%s, want %+v", i, cn.Symbol, cn.Currency, cn.Secondary, want[i])
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"context"
+	"time"
+)
+
+const defaultRequestTimeout = 30 * time.Second
+
+// cancelCallsLocked aborts provider calls in flight. With reconnect the feed itself is abandoned too
+// and the context for the new feed is returned.
+func (a *App) cancelCallsLocked(reconnect bool) context.Context {
+	if a.callCancel != nil {
+		a.callCancel()
+	}
+	if reconnect || a.feedCtx == nil {
+		if a.feedCancel != nil {
+			a.feedCancel()
+		}
+		a.feedCtx, a.feedCancel = context.WithCancel(a.ctx)
+	}
+	a.callCtx, a.callCancel = context.WithCancel(a.feedCtx)
+	return a.feedCtx
+}
+
+// requestContext bounds a single provider call by the request timeout.
+func (a *App) requestContext() (context.Context, context.CancelFunc) {
+	a.Lock()
+	ctx, timeout := a.callCtx, a.requestTimeout
+	a.Unlock()
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,126 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+	"github.com/itohio/CoinWatcher/pkg/logger"
+)
+
+const defaultCreditBudget = 10000
+
+// Credits holds API credits used per provider during Month.
+type Credits struct {
+	Month string         `json:"month"`
+	Used  map[string]int `json:"used"`
+}
+
+var _ crypto.CreditMeter = &App{}
+
+func creditsMonth(t time.Time) string {
+	return t.Format("2006-01")
+}
+
+func (a *App) loadCredits() {
+	a.creditsLock.Lock()
+	defer a.creditsLock.Unlock()
+
+	a.credits = Credits{
+		Month: creditsMonth(time.Now()),
+		Used:  make(map[string]int),
+	}
+
+	reader, err := a.reader("credits.json")
+	if err != nil {
+		return
+	}
+	defer reader.Close()
+
+	var credits Credits
+	if err := json.NewDecoder(reader).Decode(&credits); err != nil {
+		logger.Log.Error().Err(err).Msg("Could not decode credits")
+		return
+	}
+	if credits.Month == a.credits.Month && credits.Used != nil {
+		a.credits = credits
+	}
+}
+
+func (a *App) saveCreditsLocked() {
+	writer, err := a.writer("credits.json")
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Could not get credits writer")
+		return
+	}
+	defer writer.Close()
+
+	if err := json.NewEncoder(writer).Encode(&a.credits); err != nil {
+		logger.Log.Error().Err(err).Msg("Could not write credits")
+	}
+}
+
+// AddCredits records credits spent by provider in the current month.
+//
+// Implements: crypto.CreditMeter
+func (a *App) AddCredits(provider string, credits int) {
+	a.creditsLock.Lock()
+	if month := creditsMonth(time.Now()); a.credits.Month != month {
+		a.credits = Credits{
+			Month: month,
+			Used:  make(map[string]int),
+		}
+	}
+	a.credits.Used[provider] += credits
+	a.saveCreditsLocked()
+	a.creditsLock.Unlock()
+
+	a.updateCredits()
+}
+
+// creditsUsed returns CoinMarketCap credits used this month.
+func (a *App) creditsUsed() int {
+	a.creditsLock.Lock()
+	defer a.creditsLock.Unlock()
+
+	if a.credits.Month != creditsMonth(time.Now()) {
+		return 0
+	}
+	return a.credits.Used[providerCMC]
+}
+
+func (a *App) updateCredits() {
+	if a.creditsWidget == nil {
+		return
+	}
+	used := a.creditsUsed()
+	if used == 0 && a.provider != providerCMC {
+		a.creditsWidget.SetText("")
+		return
+	}
+	a.creditsWidget.SetText(fmt.Sprintf("%d/%d credits left", a.creditBudget-used, a.creditBudget))
+}
+
+// refreshInterval stretches the refresh interval so that refreshing the watchlist
+// until the end of the month does not exceed the credit budget.
+func (a *App) refreshInterval() time.Duration {
+	return a.refreshIntervalAt(time.Now())
+}
+
+func (a *App) refreshIntervalAt(now time.Time) time.Duration {
+	if !a.adaptiveInterval || a.refreshCredits == 0 {
+		return a.interval
+	}
+
+	monthLeft := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location()).Sub(now)
+	refreshes := (a.creditBudget - a.creditsUsed()) / a.refreshCredits
+	if refreshes <= 0 {
+		return monthLeft
+	}
+
+	if interval := monthLeft / time.Duration(refreshes); interval > a.interval {
+		return interval
+	}
+	return a.interval
+}
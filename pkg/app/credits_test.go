@@ -0,0 +1,39 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRefreshInterval(t *testing.T) {
+	now := time.Now()
+	// Ten days before the end of the month
+	monthEnd := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
+	at := monthEnd.Add(-time.Hour * 24 * 10)
+
+	for _, tc := range []struct {
+		name     string
+		adaptive bool
+		credits  int
+		used     int
+		want     time.Duration
+	}{
+		{"fixed", false, 10, 9000, time.Minute * 5},
+		{"unknown cost", true, 0, 9000, time.Minute * 5},
+		{"enough credits", true, 1, 0, time.Minute * 5},
+		{"stretched", true, 10, 9000, time.Hour * 24 * 10 / 100},
+		{"exhausted", true, 10, 9995, time.Hour * 24 * 10},
+		{"overspent", true, 10, 12000, time.Hour * 24 * 10},
+	} {
+		a := &App{
+			interval:         time.Minute * 5,
+			adaptiveInterval: tc.adaptive,
+			refreshCredits:   tc.credits,
+			creditBudget:     defaultCreditBudget,
+			credits:          Credits{Month: creditsMonth(now), Used: map[string]int{providerCMC: tc.used}},
+		}
+		if got := a.refreshIntervalAt(at); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
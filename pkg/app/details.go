@@ -0,0 +1,357 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+	"github.com/itohio/CoinWatcher/pkg/history"
+	"github.com/itohio/CoinWatcher/pkg/widgets/chart"
+	"github.com/itohio/CoinWatcher/pkg/widgets/coin"
+)
+
+// chartCandles is the number of candles loaded into the detail chart.
+const chartCandles = 120
+
+// showDetails shows the chart, the latest quote and metadata of a coin with actions to set a price alert or holdings.
+func (a *App) showDetails(symbol crypto.Symbol) {
+	var data *coin.CoinData
+	a.Lock()
+	for _, cd := range a.coinData {
+		if c, ok := cd.(*coin.CoinData); ok && c.Symbol.Is(symbol) {
+			data = c
+			break
+		}
+	}
+	a.Unlock()
+	if data == nil {
+		return
+	}
+	currency := data.Currency
+	quote := data.Quotes[currency]
+
+	status := widget.NewLabel("")
+	status.Wrapping = fyne.TextTruncate
+	var priceChart *chart.Chart
+	priceChart = chart.New(func(interval time.Duration) {
+		go a.loadChart(priceChart, status, symbol, currency, interval)
+	})
+	priceChart.SetInterval(time.Hour * 24)
+
+	about := container.NewVBox(widget.NewLabel("Loading..."))
+	portfolio := widget.NewLabel("")
+	portfolio.Wrapping = fyne.TextWrapWord
+	showPortfolio := func() {
+		portfolio.SetText(a.describePortfolio(symbol, quote.Price, currency))
+	}
+	showPortfolio()
+
+	alertBtn := widget.NewButtonWithIcon("Alert", theme.WarningIcon(), func() {
+		a.showAlert(symbol, currency, showPortfolio)
+	})
+	holdingsBtn := widget.NewButtonWithIcon("Holdings", theme.ContentAddIcon(), func() {
+		a.showHoldings(symbol, showPortfolio)
+	})
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem("Chart", container.NewBorder(nil, status, nil, nil, priceChart)),
+		container.NewTabItem("Quote", container.NewVScroll(quoteForm(data))),
+		container.NewTabItem("About", container.NewVScroll(about)),
+	)
+	content := container.NewBorder(
+		nil,
+		container.NewVBox(portfolio, container.NewHBox(layout.NewSpacer(), alertBtn, holdingsBtn)),
+		nil, nil,
+		tabs,
+	)
+
+	d := dialog.NewCustom(symbolOption(data.Symbol), "Close", content, a.window)
+	d.Resize(a.window.Canvas().Size())
+	d.Show()
+
+	go a.loadChart(priceChart, status, symbol, currency, priceChart.Interval())
+	go a.loadInfo(about, symbol)
+}
+
+// quoteForm lists every field of the latest quote in the display currencies.
+func quoteForm(data *coin.CoinData) *widget.Form {
+	q := data.Quotes[data.Currency]
+	item := func(name, value string) *widget.FormItem {
+		return widget.NewFormItem(name, widget.NewLabel(value))
+	}
+	percent := func(v float64) string {
+		return fmt.Sprintf("%0.2f%%", v)
+	}
+
+	form := widget.NewForm(item("Price", formatValue(q.Price)+" "+data.Currency))
+	if data.Secondary != "" {
+		form.AppendItem(item("", formatValue(data.Quotes[data.Secondary].Price)+" "+data.Secondary))
+	}
+	for _, it := range []*widget.FormItem{
+		item("Market cap", formatValue(q.MarketCap)),
+		item("Volume 24h", formatValue(q.Volume24H)),
+		item("Volume 7d", formatValue(q.Volume7D)),
+		item("Volume 30d", formatValue(q.Volume30D)),
+		item("Base volume 24h", formatValue(q.Volume24Hbase)),
+		item("Quote volume 24h", formatValue(q.Volume24Hquote)),
+		item("Change 1h", percent(q.PercentChange1H)),
+		item("Change 24h", percent(q.PercentChange24H)),
+		item("Change 7d", percent(q.PercentChange7D)),
+		item("Change 30d", percent(q.PercentChange30D)),
+	} {
+		form.AppendItem(it)
+	}
+	if !q.LastUpdated.IsZero() {
+		form.AppendItem(item("Updated", q.LastUpdated.Local().Format("2006-01-02 15:04:05")))
+	}
+	if len(q.Sources) > 0 {
+		form.AppendItem(item("Sources", strings.Join(q.Sources, ", ")))
+	} else if q.Provider != "" {
+		form.AppendItem(item("Provider", q.Provider))
+	}
+	return form
+}
+
+// loadChart shows candles from the feed, or from recorded history if the feed has none.
+func (a *App) loadChart(priceChart *chart.Chart, status *widget.Label, symbol crypto.Symbol, currency string, interval time.Duration) {
+	end := time.Now()
+	start := end.Add(-interval * chartCandles)
+	status.SetText("Loading...")
+
+	var err error
+	if feed := a.currentFeed(); feed != nil {
+		var data []crypto.Ohlcv
+		ctx, cancel := a.requestContext()
+		data, err = feed.GetOHLCV(ctx, currency, interval, start, end, symbol)
+		cancel()
+		if candles := chart.FromOhlcv(currency, data); err == nil && len(candles) > 0 {
+			if priceChart.Interval() == interval {
+				priceChart.SetCandles(candles)
+				status.SetText(feed.Name())
+			}
+			return
+		}
+	}
+
+	// Providers may not serve history on the current plan, recorded quotes are better than nothing
+	var candles []chart.Candle
+	if a.history != nil {
+		points, herr := a.history.Candles(history.SeriesOf(symbol, currency), start, end, interval)
+		if herr != nil && err == nil {
+			err = herr
+		}
+		candles = candlesOf(points)
+	}
+	if priceChart.Interval() != interval {
+		return
+	}
+	priceChart.SetCandles(candles)
+	switch {
+	case len(candles) > 0:
+		status.SetText("Recorded history")
+	case err != nil:
+		status.SetText(errorMessage(err))
+	default:
+		status.SetText("No data")
+	}
+}
+
+func candlesOf(points []history.Point) []chart.Candle {
+	ret := make([]chart.Candle, len(points))
+	for i, p := range points {
+		ret[i] = chart.Candle{
+			Time:   p.Time,
+			Open:   p.Open,
+			High:   p.High,
+			Low:    p.Low,
+			Close:  p.Price,
+			Volume: p.Volume24H,
+		}
+	}
+	return ret
+}
+
+// loadInfo fills about with coin metadata if the feed has any.
+func (a *App) loadInfo(about *fyne.Container, symbol crypto.Symbol) {
+	text := func(s string) *widget.Label {
+		ret := widget.NewLabel(s)
+		ret.Wrapping = fyne.TextWrapWord
+		return ret
+	}
+
+	describer, ok := a.currentFeed().(crypto.Describer)
+	if !ok {
+		about.Objects = []fyne.CanvasObject{text("No information")}
+		about.Refresh()
+		return
+	}
+	ctx, cancel := a.requestContext()
+	info, err := describer.GetInfo(ctx, symbol)
+	cancel()
+	if err != nil {
+		about.Objects = []fyne.CanvasObject{text(errorMessage(err))}
+		about.Refresh()
+		return
+	}
+
+	var objs []fyne.CanvasObject
+	if info.Category != "" {
+		objs = append(objs, text("Category: "+info.Category))
+	}
+	if len(info.Tags) > 0 {
+		objs = append(objs, text("Tags: "+strings.Join(info.Tags, ", ")))
+	}
+	if info.Description != "" {
+		objs = append(objs, text(info.Description))
+	}
+	for _, links := range []struct {
+		name string
+		urls []string
+	}{
+		{"Website", info.Website},
+		{"Explorer", info.Explorer},
+		{"Source code", info.SourceCode},
+	} {
+		for _, s := range links.urls {
+			if u, err := url.Parse(s); err == nil && u.Host != "" {
+				objs = append(objs, widget.NewHyperlink(links.name+": "+u.Host, u))
+			}
+		}
+	}
+	if len(objs) == 0 {
+		objs = append(objs, text("No information"))
+	}
+	about.Objects = objs
+	about.Refresh()
+}
+
+// describePortfolio tells how much of a coin is held and which alert is set.
+func (a *App) describePortfolio(symbol crypto.Symbol, price float64, currency string) string {
+	amount, alert, ok := a.holding(symbol)
+	var lines []string
+	if amount > 0 {
+		lines = append(lines, fmt.Sprintf("Holding %s %s worth %s %s", strconv.FormatFloat(amount, 'f', -1, 64), symbol.Symbol, formatValue(amount*price), currency))
+	}
+	if ok {
+		var bounds []string
+		if alert.Above > 0 {
+			bounds = append(bounds, "above "+formatValue(alert.Above))
+		}
+		if alert.Below > 0 {
+			bounds = append(bounds, "below "+formatValue(alert.Below))
+		}
+		lines = append(lines, fmt.Sprintf("Alert %s %s", strings.Join(bounds, " or "), alert.Currency))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// showAlert asks for price bounds in currency to be notified about.
+func (a *App) showAlert(symbol crypto.Symbol, currency string, onChanged func()) {
+	_, alert, _ := a.holding(symbol)
+	if alert.Currency != currency {
+		alert = Alert{Currency: currency}
+	}
+	validator := func(s string) error {
+		if strings.TrimSpace(s) == "" {
+			return nil
+		}
+		_, err := strconv.ParseFloat(s, 64)
+		return err
+	}
+	above := widget.NewEntry()
+	above.SetPlaceHolder("not set")
+	above.Validator = validator
+	below := widget.NewEntry()
+	below.SetPlaceHolder("not set")
+	below.Validator = validator
+	if alert.Above > 0 {
+		above.Text = fmt.Sprint(alert.Above)
+	}
+	if alert.Below > 0 {
+		below.Text = fmt.Sprint(alert.Below)
+	}
+
+	dialog.ShowForm(
+		fmt.Sprintf("%s price alert", symbol.Symbol),
+		"Save",
+		"Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Above, "+currency, above),
+			widget.NewFormItem("Below, "+currency, below),
+		},
+		func(b bool) {
+			if !b {
+				return
+			}
+			alert.Above, _ = strconv.ParseFloat(strings.TrimSpace(above.Text), 64)
+			alert.Below, _ = strconv.ParseFloat(strings.TrimSpace(below.Text), 64)
+			a.setAlert(symbol, alert)
+			onChanged()
+		},
+		a.window,
+	)
+}
+
+// showHoldings asks for the amount held of a coin.
+func (a *App) showHoldings(symbol crypto.Symbol, onChanged func()) {
+	held, _, _ := a.holding(symbol)
+	amount := widget.NewEntry()
+	amount.SetPlaceHolder("0")
+	if held > 0 {
+		amount.Text = fmt.Sprint(held)
+	}
+	amount.Validator = func(s string) error {
+		if strings.TrimSpace(s) == "" {
+			return nil
+		}
+		_, err := strconv.ParseFloat(s, 64)
+		return err
+	}
+
+	dialog.ShowForm(
+		fmt.Sprintf("%s holdings", symbol.Symbol),
+		"Save",
+		"Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Amount", amount),
+		},
+		func(b bool) {
+			if !b {
+				return
+			}
+			n, _ := strconv.ParseFloat(strings.TrimSpace(amount.Text), 64)
+			a.setHolding(symbol, n)
+			onChanged()
+		},
+		a.window,
+	)
+}
+
+// formatValue shows large values with a magnitude suffix and small ones with four significant digits.
+func formatValue(v float64) string {
+	switch n := math.Abs(v); {
+	case n >= 1e12:
+		return fmt.Sprintf("%0.2fT", v/1e12)
+	case n >= 1e9:
+		return fmt.Sprintf("%0.2fB", v/1e9)
+	case n >= 1e6:
+		return fmt.Sprintf("%0.2fM", v/1e6)
+	case n >= 1e3:
+		return fmt.Sprintf("%0.2f", v)
+	case n >= 1:
+		return fmt.Sprintf("%0.4f", v)
+	case n == 0:
+		return "0"
+	}
+	return strconv.FormatFloat(v, 'f', 3-int(math.Floor(math.Log10(math.Abs(v)))), 64)
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+)
+
+func TestFormatValue(t *testing.T) {
+	for _, tc := range []struct {
+		v    float64
+		want string
+	}{
+		{0, "0"},
+		{1.5e12, "1.50T"},
+		{2.345e9, "2.35B"},
+		{-3e6, "-3.00M"},
+		{1234.567, "1234.57"},
+		{12.34567, "12.3457"},
+		{1, "1.0000"},
+		{0.123456, "0.1235"},
+		{0.000012346, "0.00001235"},
+		{-0.5, "-0.5000"},
+	} {
+		if got := formatValue(tc.v); got != tc.want {
+			t.Errorf("formatValue(%v) = %q, want %q", tc.v, got, tc.want)
+		}
+	}
+}
+
+func TestDescribePortfolio(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		holding float64
+		alert   *Alert
+		symbol  crypto.Symbol
+		want    string
+	}{
+		{"nothing", 0, nil, btc, ""},
+		{"holding", 0.5, nil, btc, "Holding 0.5 BTC worth 25000.00 USD"},
+		{"alert", 0, &Alert{Currency: "EUR", Above: 60000}, btc, "Alert above 60000.00 EUR"},
+		{"both", 2, &Alert{Currency: "USD", Above: 60000, Below: 40000}, btc, "Holding 2 BTC worth 100000.00 USD\nAlert above 60000.00 or below 40000.00 USD"},
+		{"other coin", 2, &Alert{Currency: "USD", Above: 60000}, cmcBTC, ""},
+	} {
+		a := &App{}
+		a.portfolio.Holdings = map[string]float64{coinKey(btc): tc.holding}
+		if tc.alert != nil {
+			a.portfolio.Alerts = map[string]Alert{coinKey(btc): *tc.alert}
+		}
+		if got := a.describePortfolio(tc.symbol, 50000, "USD"); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+)
+
+// formatPairs lists DEX pairs one per line as "token pair".
+func formatPairs(pairs ...crypto.DEXPair) string {
+	lines := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		if p.Token != "" || p.Pair != "" {
+			lines = append(lines, p.Token+" "+p.Pair)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parsePairs parses DEX pairs formatted by formatPairs.
+func parsePairs(s string) ([]crypto.DEXPair, error) {
+	var ret []crypto.DEXPair
+	for _, line := range strings.Split(s, "\n") {
+		fields := strings.Fields(line)
+		switch len(fields) {
+		case 0:
+			continue
+		case 2:
+			ret = append(ret, crypto.DEXPair{Token: fields[0], Pair: fields[1]})
+		default:
+			return nil, fmt.Errorf("expected token and pair addresses: %q", line)
+		}
+	}
+	return ret, nil
+}
+
+// dexConfigured reports whether the DEX provider has enough settings to connect.
+func (a *App) dexConfigured() bool {
+	return a.dex.RPC != "" && a.dex.Reference.Pair != ""
+}
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+)
+
+// errorMessage describes provider errors in terms of what the user can do about them.
+func errorMessage(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, crypto.ErrUnauthorized):
+		return "API key was rejected, check it in settings"
+	case errors.Is(err, crypto.ErrPlanRestricted):
+		return "Not available with your API plan"
+	case errors.Is(err, crypto.ErrRateLimited):
+		if after, ok := crypto.RetryAfter(err); ok && after > 0 {
+			return fmt.Sprintf("Rate limited until %s", time.Now().Add(after).Format("Jan 2 15:04"))
+		}
+		return "Rate limited, quotes will be refreshed later"
+	case errors.Is(err, crypto.ErrUnknownSymbol):
+		return fmt.Sprintf("Not listed by the provider (%v)", err)
+	}
+	return err.Error()
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/CoinWatcher/pkg/history"
+	"github.com/itohio/CoinWatcher/pkg/logger"
+	"github.com/itohio/CoinWatcher/pkg/widgets/coin"
+)
+
+// exportFormats maps format names shown in the export dialog to history formats.
+var exportFormats = map[string]string{
+	"CSV":        history.CSV,
+	"JSON lines": history.JSONL,
+}
+
+// showExport asks which recorded quotes to export and where to save them.
+func (a *App) showExport() {
+	if a.history == nil {
+		dialog.ShowInformation("Export", "Quote history is not recorded", a.window)
+		return
+	}
+	series, err := a.history.Series()
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+
+	var currencies, symbols []string
+	for _, s := range series {
+		if !contains(currencies, s.Currency) {
+			currencies = append(currencies, s.Currency)
+		}
+	}
+	sort.Strings(currencies)
+	a.Lock()
+	for _, cd := range a.coinData {
+		if c, ok := cd.(*coin.CoinData); ok && !contains(symbols, c.Symbol.Symbol) {
+			symbols = append(symbols, c.Symbol.Symbol)
+		}
+	}
+	a.Unlock()
+
+	symbolCheck := widget.NewCheckGroup(symbols, nil)
+	symbolCheck.Horizontal = true
+	symbolCheck.SetSelected(symbols)
+	currency := widget.NewSelect(currencies, nil)
+	currency.SetSelected(a.currency)
+	if currency.SelectedIndex() < 0 && len(currencies) > 0 {
+		currency.SetSelectedIndex(0)
+	}
+	timezone := widget.NewEntry()
+	timezone.Text = "Local"
+	timezone.Validator = func(s string) error {
+		_, err := time.LoadLocation(s)
+		return err
+	}
+	validTime := func(s string) error {
+		if strings.TrimSpace(s) == "" {
+			return nil
+		}
+		_, err := history.ParseTime(s, nil)
+		return err
+	}
+	from := widget.NewEntry()
+	from.Text = time.Now().AddDate(0, -1, 0).Format("2006-01-02")
+	from.SetPlaceHolder("YYYY-MM-DD [hh:mm], empty from the start")
+	from.Validator = validTime
+	to := widget.NewEntry()
+	to.SetPlaceHolder("YYYY-MM-DD [hh:mm], empty until now")
+	to.Validator = validTime
+	format := widget.NewSelect([]string{"CSV", "JSON lines"}, nil)
+	format.SetSelectedIndex(0)
+
+	dialog.ShowForm(
+		"Export history",
+		"Export",
+		"Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Coins", symbolCheck),
+			widget.NewFormItem("Currency", currency),
+			widget.NewFormItem("From", from),
+			widget.NewFormItem("To", to),
+			widget.NewFormItem("Time zone", timezone),
+			widget.NewFormItem("Format", format),
+		},
+		func(b bool) {
+			if !b {
+				return
+			}
+			opts := history.ExportOptions{
+				Symbols:  symbolCheck.Selected,
+				Currency: currency.Selected,
+				Format:   exportFormats[format.Selected],
+			}
+			if len(opts.Symbols) == 0 {
+				dialog.ShowError(errors.New("no coins selected"), a.window)
+				return
+			}
+			opts.Location, _ = time.LoadLocation(timezone.Text)
+			if strings.TrimSpace(from.Text) != "" {
+				opts.From, _ = history.ParseTime(from.Text, opts.Location)
+			}
+			if strings.TrimSpace(to.Text) != "" {
+				opts.To, _ = history.ParseTime(to.Text, opts.Location)
+			}
+			a.saveExport(opts)
+		},
+		a.window,
+	)
+}
+
+func (a *App) saveExport(opts history.ExportOptions) {
+	save := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+		if err != nil || w == nil {
+			return
+		}
+		defer w.Close()
+
+		n, err := a.history.Export(w, opts)
+		if err != nil {
+			logger.Log.Error().Err(err).Str("file", w.URI().String()).Msg("Could not export history")
+			dialog.ShowError(err, a.window)
+			return
+		}
+		dialog.ShowInformation("Export", fmt.Sprintf("Exported %d quotes to %s", n, w.URI().Name()), a.window)
+	}, a.window)
+	save.SetFileName("history." + opts.Format)
+	save.Show()
+}
@@ -0,0 +1,249 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+	"github.com/itohio/CoinWatcher/pkg/logger"
+)
+
+const (
+	providerCMC       = "CoinMarketCap"
+	providerCoinGecko = "CoinGecko"
+	providerBinance   = "Binance"
+	providerDEX       = "DEX"
+	providerAggregate = "Aggregate"
+	providerFailover  = "Failover"
+
+	failoverErrors   = 3
+	failoverCooldown = time.Hour
+
+	// defaultMaxDeviation is the percentage by which aggregated quotes may deviate from the median
+	defaultMaxDeviation = 5
+
+	noCurrency = "None"
+)
+
+var providers = []string{providerCMC, providerCoinGecko, providerBinance, providerDEX, providerAggregate, providerFailover}
+
+func (a *App) newFeed(ctx context.Context) (crypto.Crypto, error) {
+	if a.options.Replay != "" {
+		return crypto.NewReplay(a.options.Replay, a.options.ReplaySpeed, a)
+	}
+
+	feed, err := a.newProvider(ctx, a.provider)
+	if err != nil || a.options.Record == "" {
+		return feed, err
+	}
+
+	recorder, err := crypto.NewRecorder(feed, a.options.Record)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Could not start recording")
+		return feed, nil
+	}
+	return recorder, nil
+}
+
+// newProviders creates every standalone provider that could be reached.
+func (a *App) newProviders(ctx context.Context) ([]crypto.Crypto, error) {
+	var feeds []crypto.Crypto
+	for _, p := range []string{providerCMC, providerCoinGecko, providerBinance, providerDEX} {
+		if p == providerCMC && a.apiKey == "" || p == providerDEX && !a.dexConfigured() {
+			continue
+		}
+		feed, err := a.newProvider(ctx, p)
+		if err == nil {
+			err = waitLoaded(feed)
+		}
+		if err != nil {
+			logger.Log.Error().Err(err).Str("provider", p).Msg("Skipping provider")
+			continue
+		}
+		feeds = append(feeds, feed)
+	}
+	if len(feeds) == 0 {
+		return nil, errors.New("no provider is available")
+	}
+	return feeds, nil
+}
+
+func (a *App) newProvider(ctx context.Context, provider string) (crypto.Crypto, error) {
+	switch provider {
+	case providerAggregate:
+		feeds, err := a.newProviders(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.NewAggregate(a.maxDeviation, feeds...), nil
+	case providerFailover:
+		feeds, err := a.newProviders(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.NewFailover(failoverErrors, failoverCooldown, a.onFailover, feeds...), nil
+	case providerCoinGecko:
+		return crypto.NewCoinGecko(ctx, "", a)
+	case providerBinance:
+		return crypto.NewBinance(ctx, "", a)
+	case providerDEX:
+		return crypto.NewDEX(ctx, a.dex)
+	default:
+		return crypto.NewCMC(ctx, a.apiKey, a, a, a, a.listingTTLFor())
+	}
+}
+
+// closeRecorder closes the fixture file of the current feed.
+func (a *App) closeRecorder() {
+	a.Lock()
+	recorder := a.recorder
+	a.recorder = nil
+	a.Unlock()
+	if recorder == nil {
+		return
+	}
+	if err := recorder.Close(); err != nil {
+		logger.Log.Error().Err(err).Msg("Could not close recording")
+	}
+}
+
+// waitLoaded blocks until feed has loaded its listings.
+func waitLoaded(feed crypto.Crypto) error {
+	if loader, ok := feed.(crypto.Loader); ok {
+		<-loader.Loaded()
+		return loader.Err()
+	}
+	return nil
+}
+
+// connect recreates the feed in the background. Controls that need the feed stay disabled until it is ready.
+// Calls to the previous feed are cancelled.
+func (a *App) connect() {
+	a.Lock()
+	a.feedGen++
+	gen := a.feedGen
+	a.feed = nil
+	ctx := a.cancelCallsLocked(true)
+	a.Unlock()
+
+	a.closeRecorder()
+	a.restartStream()
+	a.setConnected(nil)
+
+	go func() {
+		feed, err := a.newFeed(ctx)
+		a.Lock()
+		a.forceListings = false
+		a.Unlock()
+		recorder, _ := feed.(io.Closer)
+		if err == nil {
+			err = waitLoaded(feed)
+		}
+		if err == nil {
+			a.Lock()
+			timeout := a.requestTimeout
+			a.Unlock()
+			feed = crypto.NewConverter(ctx, feed, a.rates, timeout)
+		}
+
+		a.Lock()
+		current := gen == a.feedGen
+		if current && err == nil {
+			a.feed = feed
+			a.recorder = recorder
+		}
+		a.Unlock()
+		// The recording of a feed that is not used is closed, otherwise it is kept until the next connect
+		if (!current || err != nil) && recorder != nil {
+			recorder.Close()
+		}
+		if !current {
+			return
+		}
+
+		if err != nil {
+			logger.Log.Error().Err(err).Str("provider", a.provider).Msg("Could not connect")
+			a.setConnected(err)
+			if errors.Is(err, crypto.ErrUnauthorized) {
+				dialog.ShowInformation("Could not connect", errorMessage(err), a.window)
+			}
+			return
+		}
+		a.onConnected(feed)
+	}()
+}
+
+// currentFeed returns the feed, nil while not connected.
+func (a *App) currentFeed() crypto.Crypto {
+	a.Lock()
+	defer a.Unlock()
+	return a.feed
+}
+
+func (a *App) onConnected(feed crypto.Crypto) {
+	currencies := feed.GetCurrencies()
+	if !contains(currencies, a.currency) && len(currencies) > 0 {
+		a.currency = currencies[0]
+		if contains(currencies, "USD") {
+			a.currency = "USD"
+		}
+		a.saveSettings()
+	}
+	if a.secondary != "" && !contains(currencies, a.secondary) {
+		a.secondary = ""
+		a.saveSettings()
+	}
+	a.updateCurrencies()
+
+	a.Lock()
+	empty := len(a.coinData) == 0
+	a.Unlock()
+	if empty {
+		a.defaultCoins(feed)
+	} else {
+		a.resolveCoins(feed)
+	}
+	a.applyCurrencies()
+
+	a.setConnected(nil)
+	a.updateQuotes()
+	a.restartStream()
+}
+
+// setConnected enables or disables controls that need the feed and reports err in the status area.
+func (a *App) setConnected(err error) {
+	connected := a.currentFeed() != nil
+	for _, w := range []*widget.Select{a.currencyWidget, a.secondaryWidget} {
+		if w == nil {
+			continue
+		}
+		if connected {
+			w.Enable()
+		} else {
+			w.Disable()
+		}
+	}
+
+	switch {
+	case err != nil:
+		a.setStatus(errorMessage(err))
+	case !connected:
+		a.setStatus(fmt.Sprintf("connecting to %s...", a.provider))
+	default:
+		a.setStatus("")
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+	"github.com/itohio/CoinWatcher/pkg/history"
+	"github.com/itohio/CoinWatcher/pkg/logger"
+)
+
+// compactInterval is how often history retention is applied.
+const compactInterval = time.Hour * 6
+
+// HistoryDir returns the directory where the watcher records quote history. It does not start the app.
+func HistoryDir() string {
+	return filepath.Join(storageRoot(), appID, "history")
+}
+
+// storageRoot is where fyne keeps storage of desktop apps. It copies the unexported rootConfigDir
+// of fyne v2.1.2 so that the command line finds the history without creating an app,
+// TestStorageRoot checks that they still match.
+func storageRoot() string {
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(home, "Library", "Preferences", "fyne")
+	case "windows":
+		return filepath.Join(home, "AppData", "Roaming", "fyne")
+	}
+	config, _ := os.UserConfigDir()
+	return filepath.Join(config, "fyne")
+}
+
+func historyDir(a fyne.App) string {
+	return filepath.Join(a.Storage().RootURI().Path(), "history")
+}
+
+// openHistory opens the quote history in app storage and compacts it in the background.
+// Replayed quotes are not recorded.
+func (a *App) openHistory() {
+	if a.options.Replay != "" {
+		return
+	}
+	store, err := history.Open(historyDir(a.app))
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Could not open history")
+		return
+	}
+	// Quotes are not recorded while the history is imported from the command line
+	if err := store.Lock(); err != nil {
+		logger.Log.Error().Err(err).Msg("Could not lock history")
+		return
+	}
+	a.history = store
+
+	go func() {
+		ticker := time.NewTicker(compactInterval)
+		defer ticker.Stop()
+		for {
+			a.Lock()
+			retention := a.retention
+			a.Unlock()
+			if err := store.Compact(a.ctx, retention, time.Now()); err != nil && a.ctx.Err() == nil {
+				logger.Log.Error().Err(err).Msg("Could not compact history")
+			}
+
+			select {
+			case <-a.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (a *App) recordQuote(currency string, quote crypto.Quote) {
+	if a.history == nil {
+		return
+	}
+	if err := a.history.AppendQuotes(currency, quote); err != nil {
+		logger.Log.Error().Err(err).Str("symbol", quote.Symbol.Symbol).Msg("Could not record quote")
+	}
+}
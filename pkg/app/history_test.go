@@ -0,0 +1,17 @@
+//go:build !ci
+// +build !ci
+
+package app
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2/app"
+)
+
+// TestStorageRoot needs the desktop driver, the ci driver stores apps in a temporary directory.
+func TestStorageRoot(t *testing.T) {
+	if got, want := HistoryDir(), historyDir(app.NewWithID(appID)); got != want {
+		t.Errorf("got history in %s, the app records it in %s", got, want)
+	}
+}
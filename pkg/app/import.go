@@ -0,0 +1,126 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+	"github.com/itohio/CoinWatcher/pkg/history"
+	"github.com/itohio/CoinWatcher/pkg/logger"
+	"github.com/itohio/CoinWatcher/pkg/widgets/coin"
+)
+
+// maxRejectedShown limits rejected rows listed after an import.
+const maxRejectedShown = 10
+
+// showImport asks for a coin and a CSV file of historical prices to backfill its history.
+func (a *App) showImport() {
+	if a.history == nil {
+		dialog.ShowInformation("Import", "Quote history is not recorded", a.window)
+		return
+	}
+
+	var (
+		symbols []crypto.Symbol
+		names   []string
+	)
+	a.Lock()
+	for _, cd := range a.coinData {
+		if c, ok := cd.(*coin.CoinData); ok && !contains(names, c.Symbol.Symbol) {
+			symbols = append(symbols, c.Symbol)
+			names = append(names, c.Symbol.Symbol)
+		}
+	}
+	a.Unlock()
+
+	symbol := widget.NewSelect(names, nil)
+	if len(names) > 0 {
+		symbol.SetSelectedIndex(0)
+	}
+	currency := widget.NewEntry()
+	currency.Text = a.currency
+	timezone := widget.NewEntry()
+	timezone.Text = "UTC"
+	timezone.Validator = func(s string) error {
+		_, err := time.LoadLocation(s)
+		return err
+	}
+	columns := make([]*widget.Entry, 6)
+	for i := range columns {
+		columns[i] = widget.NewEntry()
+		columns[i].SetPlaceHolder("detect")
+	}
+
+	dialog.ShowForm(
+		"Import history",
+		"Choose file",
+		"Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Coin", symbol),
+			widget.NewFormItem("Currency", currency),
+			widget.NewFormItem("Time zone", timezone),
+			widget.NewFormItem("Date column", columns[0]),
+			widget.NewFormItem("Open column", columns[1]),
+			widget.NewFormItem("High column", columns[2]),
+			widget.NewFormItem("Low column", columns[3]),
+			widget.NewFormItem("Close column", columns[4]),
+			widget.NewFormItem("Volume column", columns[5]),
+		},
+		func(b bool) {
+			if !b || symbol.SelectedIndex() < 0 {
+				return
+			}
+			opts := history.ImportOptions{
+				Symbol:   symbols[symbol.SelectedIndex()],
+				Currency: strings.ToUpper(strings.TrimSpace(currency.Text)),
+				Columns: history.Columns{
+					Time:   columns[0].Text,
+					Open:   columns[1].Text,
+					High:   columns[2].Text,
+					Low:    columns[3].Text,
+					Close:  columns[4].Text,
+					Volume: columns[5].Text,
+				},
+			}
+			opts.Location, _ = time.LoadLocation(timezone.Text)
+			a.openImport(opts)
+		},
+		a.window,
+	)
+}
+
+func (a *App) openImport(opts history.ImportOptions) {
+	dialog.ShowFileOpen(func(r fyne.URIReadCloser, err error) {
+		if err != nil || r == nil {
+			return
+		}
+		defer r.Close()
+
+		result, err := a.history.Import(r, opts)
+		if err != nil {
+			logger.Log.Error().Err(err).Str("file", r.URI().String()).Msg("Could not import history")
+			dialog.ShowError(err, a.window)
+			return
+		}
+		dialog.ShowInformation("Import", importSummary(opts.Symbol.Symbol, result), a.window)
+	}, a.window)
+}
+
+func importSummary(symbol string, result history.ImportResult) string {
+	lines := []string{fmt.Sprintf("Imported %d %s quotes, skipped %d duplicates", result.Imported, symbol, result.Duplicates)}
+	if len(result.Rejected) > 0 {
+		lines = append(lines, fmt.Sprintf("Rejected %d rows:", len(result.Rejected)))
+		for i, r := range result.Rejected {
+			if i == maxRejectedShown {
+				lines = append(lines, "...")
+				break
+			}
+			lines = append(lines, r.Error())
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -2,21 +2,23 @@ package app
 
 import (
 	"fmt"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/CoinWatcher/pkg/crypto"
 	"github.com/itohio/CoinWatcher/pkg/widgets/coin"
 )
 
 func (a *App) makeList() *widget.List {
 	list := widget.NewListWithData(a.data,
 		func() fyne.CanvasObject {
-			return coin.New(func(symbol string) {
+			return coin.New(func(symbol crypto.Symbol) {
 				dialog.ShowConfirm(
 					"Delete",
-					fmt.Sprintf("You are about to delete %s.\nAre you sure?", symbol),
+					fmt.Sprintf("You are about to delete %s.\nAre you sure?", symbol.Symbol),
 					func(b bool) {
 						if b {
 							a.delSymbol(symbol)
@@ -24,12 +26,23 @@ func (a *App) makeList() *widget.List {
 					},
 					a.window,
 				)
-			})
+			}, a.showDetails)
 		},
 		func(i binding.DataItem, o fyne.CanvasObject) {
-			o.(*coin.CoinWidget).Bind(i.(binding.Untyped))
+			w := o.(*coin.CoinWidget)
+			w.SetStaleAfter(a.staleThreshold())
+			w.Bind(i.(binding.Untyped))
 		},
 	)
 
 	return list
 }
+
+// staleThreshold returns the age after which quotes are flagged as stale.
+// Unless configured it is twice the refresh interval.
+func (a *App) staleThreshold() time.Duration {
+	if a.staleAfter > 0 {
+		return a.staleAfter
+	}
+	return 2 * a.refreshInterval()
+}
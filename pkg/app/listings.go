@@ -0,0 +1,69 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+	"github.com/itohio/CoinWatcher/pkg/logger"
+)
+
+const defaultListingTTL = 24 * time.Hour
+
+var _ crypto.ListingCache = &App{}
+
+func listingFile(provider string) string {
+	return fmt.Sprintf("listing_%s.json", provider)
+}
+
+// LoadListing reads cached symbol listings of provider.
+//
+// Implements: crypto.ListingCache
+func (a *App) LoadListing(provider string) (crypto.Listing, error) {
+	var listing crypto.Listing
+
+	reader, err := a.reader(listingFile(provider))
+	if err != nil {
+		return listing, err
+	}
+	defer reader.Close()
+
+	err = json.NewDecoder(reader).Decode(&listing)
+	return listing, err
+}
+
+// SaveListing caches symbol listings of provider.
+//
+// Implements: crypto.ListingCache
+func (a *App) SaveListing(provider string, listing crypto.Listing) {
+	writer, err := a.writer(listingFile(provider))
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Could not get listing writer")
+		return
+	}
+	defer writer.Close()
+
+	if err := json.NewEncoder(writer).Encode(&listing); err != nil {
+		logger.Log.Error().Err(err).Str("provider", provider).Msg("Could not write listing")
+	}
+}
+
+// refreshListings reconnects ignoring cached symbol listings.
+func (a *App) refreshListings() {
+	a.Lock()
+	a.forceListings = true
+	a.Unlock()
+
+	a.connect()
+}
+
+// listingTTLFor returns how long cached listings are used, zero if a refresh was forced.
+func (a *App) listingTTLFor() time.Duration {
+	a.Lock()
+	defer a.Unlock()
+	if a.forceListings {
+		return 0
+	}
+	return a.listingTTL
+}
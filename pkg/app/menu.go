@@ -1,7 +1,10 @@
 package app
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
@@ -36,6 +39,7 @@ func (a *App) makeMenu() *fyne.Container {
 				func(b bool) {
 					if b {
 						a.loadCoins()
+						a.restartStream()
 					}
 				},
 				a.window,
@@ -44,7 +48,25 @@ func (a *App) makeMenu() *fyne.Container {
 		widget.NewToolbarAction(theme.ContentAddIcon(), func() {
 			a.addNewSymbol()
 		}),
+		widget.NewToolbarAction(theme.StorageIcon(), func() {
+			dialog.ShowConfirm(
+				"Refresh coin listings",
+				"You are about to download coin listings again.\nThis may use API credits. Are you sure?",
+				func(b bool) {
+					if b {
+						a.refreshListings()
+					}
+				},
+				a.window,
+			)
+		}),
 		widget.NewToolbarSpacer(),
+		widget.NewToolbarAction(theme.FolderOpenIcon(), func() {
+			a.showImport()
+		}),
+		widget.NewToolbarAction(theme.DocumentIcon(), func() {
+			a.showExport()
+		}),
 		widget.NewToolbarAction(theme.SettingsIcon(), func() {
 			a.showSettings()
 		}),
@@ -52,28 +74,54 @@ func (a *App) makeMenu() *fyne.Container {
 	)
 
 	a.currencyWidget = widget.NewSelect([]string{}, func(s string) {
+		if s == a.currency {
+			return
+		}
 		a.currency = s
-		a.updateQuotes()
-		a.saveSettings()
+		a.onCurrencyChanged()
+	})
+	a.secondaryWidget = widget.NewSelect([]string{}, func(s string) {
+		if s == noCurrency {
+			s = ""
+		}
+		if s == a.secondary {
+			return
+		}
+		a.secondary = s
+		a.onCurrencyChanged()
 	})
 	a.updateCurrencies()
 
-	return container.NewBorder(nil, nil, nil, a.currencyWidget, menu)
+	return container.NewBorder(nil, nil, nil, container.NewHBox(a.currencyWidget, a.secondaryWidget), menu)
+}
+
+func (a *App) onCurrencyChanged() {
+	a.applyCurrencies()
+	a.updateQuotes()
+	a.restartStream()
+	a.saveSettings()
 }
 
 func (a *App) addNewSymbol() {
-	if a.feed == nil {
-		dialog.ShowInformation("Add Coin", "Please connect to Coinmarketcap.", a.window)
+	feed := a.currentFeed()
+	if feed == nil {
+		dialog.ShowInformation("Add Coin", fmt.Sprintf("Not connected to %s yet.", a.provider), a.window)
 		return
 	}
 
-	symbols := a.feed.GetSymbols()
+	symbols := feed.GetSymbols()
 	options := make([]string, 0, len(symbols))
+	byOption := make(map[string]crypto.Symbol, len(symbols))
 	for _, s := range symbols {
-		if _, ok := a.getSymbol(s.Symbol); ok {
+		if _, ok := a.getSymbol(s); ok {
 			continue
 		}
-		options = append(options, fmt.Sprintf("%s (%s)", s.Symbol, s.Name))
+		option := symbolOption(s)
+		if _, ok := byOption[option]; ok {
+			option = fmt.Sprintf("%s #%d", option, s.Id)
+		}
+		byOption[option] = s
+		options = append(options, option)
 	}
 
 	symbolSelect := widget.NewSelectEntry(options)
@@ -88,8 +136,13 @@ func (a *App) addNewSymbol() {
 			if !b {
 				return
 			}
-			if s, ok := a.lookupSymbol(symbolSelect.Text); ok {
+			s, ok := byOption[symbolSelect.Text]
+			if !ok {
+				s, ok = a.lookupSymbol(symbolSelect.Text)
+			}
+			if ok {
 				a.addSymbol(s)
+				a.restartStream()
 			} else {
 				dialog.ShowError(fmt.Errorf("Could not find such a coin: %s", symbolSelect.Text), a.window)
 			}
@@ -126,8 +179,112 @@ func (a *App) showSettings() {
 		time.Hour * 12,
 		time.Hour * 24,
 	}
+	provider := widget.NewSelect(providers, nil)
+	provider.SetSelected(a.provider)
+	if provider.SelectedIndex() < 0 {
+		provider.SetSelectedIndex(0)
+	}
 	apiKey := widget.NewEntry()
 	apiKey.Text = a.apiKey
+	budget := widget.NewEntry()
+	budget.Text = fmt.Sprint(a.creditBudget)
+	budget.Validator = func(s string) error {
+		_, err := strconv.Atoi(s)
+		return err
+	}
+	adaptive := widget.NewCheck("", nil)
+	adaptive.SetChecked(a.adaptiveInterval)
+	streaming := widget.NewCheck("", nil)
+	streaming.SetChecked(a.streaming)
+	deviation := widget.NewEntry()
+	deviation.Text = fmt.Sprint(a.maxDeviation)
+	deviation.Validator = func(s string) error {
+		_, err := strconv.ParseFloat(s, 64)
+		return err
+	}
+	listingTTL := widget.NewEntry()
+	listingTTL.Text = fmt.Sprint(int(a.listingTTL / time.Hour))
+	listingTTL.Validator = func(s string) error {
+		_, err := strconv.Atoi(s)
+		return err
+	}
+	timeout := widget.NewEntry()
+	timeout.Text = fmt.Sprint(int(a.requestTimeout / time.Second))
+	timeout.Validator = func(s string) error {
+		_, err := strconv.Atoi(s)
+		return err
+	}
+	staleAfter := widget.NewEntry()
+	if a.staleAfter > 0 {
+		staleAfter.Text = fmt.Sprint(int(a.staleAfter / time.Minute))
+	}
+	staleAfter.SetPlaceHolder("twice the refresh interval")
+	staleAfter.Validator = func(s string) error {
+		if s == "" {
+			return nil
+		}
+		_, err := strconv.Atoi(s)
+		return err
+	}
+	retention := make([]*widget.Entry, 3)
+	for i, d := range []time.Duration{a.retention.Raw, a.retention.Hourly, a.retention.Daily} {
+		retention[i] = widget.NewEntry()
+		retention[i].Text = fmt.Sprint(int(d / (time.Hour * 24)))
+		retention[i].SetPlaceHolder("0 keeps forever")
+		retention[i].Validator = func(s string) error {
+			_, err := strconv.Atoi(s)
+			return err
+		}
+	}
+	sparkWindow := widget.NewSelect(sparklineOptions, nil)
+	sparkWindow.SetSelectedIndex(1)
+	for i, d := range sparklineOptionsDur {
+		if d == a.sparkWindow {
+			sparkWindow.SetSelectedIndex(i)
+		}
+	}
+	rpc := widget.NewEntry()
+	rpc.Text = a.dex.RPC
+	rpc.SetPlaceHolder("Ethereum JSON-RPC url")
+	reference := widget.NewEntry()
+	reference.Text = formatPairs(a.dex.Reference)
+	reference.SetPlaceHolder("token pair, e.g. WETH and WETH/USDC addresses")
+	reference.Validator = func(s string) error {
+		pairs, err := parsePairs(s)
+		if err == nil && len(pairs) > 1 {
+			err = errors.New("only one reference pair")
+		}
+		return err
+	}
+	dexPairs := widget.NewMultiLineEntry()
+	dexPairs.Text = formatPairs(a.dex.Pairs...)
+	dexPairs.SetPlaceHolder("token pair, one per line")
+	dexPairs.Validator = func(s string) error {
+		_, err := parsePairs(s)
+		return err
+	}
+	proxy := widget.NewEntry()
+	proxy.Text = a.http.Proxy
+	proxy.SetPlaceHolder("from environment")
+	noProxy := widget.NewEntry()
+	noProxy.Text = a.http.NoProxy
+	caFile := widget.NewEntry()
+	caFile.Text = a.http.CAFile
+	caFile.SetPlaceHolder("PEM file")
+	userAgent := widget.NewEntry()
+	userAgent.Text = a.http.UserAgent
+	dialTimeout := widget.NewEntry()
+	dialTimeout.Text = fmt.Sprint(int(a.http.DialTimeout / time.Second))
+	dialTimeout.Validator = func(s string) error {
+		_, err := strconv.Atoi(s)
+		return err
+	}
+	maxConns := widget.NewEntry()
+	maxConns.Text = fmt.Sprint(a.http.MaxConns)
+	maxConns.Validator = func(s string) error {
+		_, err := strconv.Atoi(s)
+		return err
+	}
 	interval := widget.NewSelect(options[:], nil)
 
 	for i := range options {
@@ -137,61 +294,230 @@ func (a *App) showSettings() {
 		}
 	}
 
-	dialog.ShowForm(
+	form := func(items ...*widget.FormItem) fyne.CanvasObject {
+		return container.NewVScroll(widget.NewForm(items...))
+	}
+	tabs := container.NewAppTabs(
+		container.NewTabItem("Provider", form(
+			widget.NewFormItem("Provider", provider),
+			widget.NewFormItem("API Key", apiKey),
+			widget.NewFormItem("Refresh interval", interval),
+			widget.NewFormItem("Monthly credits", budget),
+			widget.NewFormItem("Fit refresh to credits", adaptive),
+			widget.NewFormItem("Max deviation, %", deviation),
+			widget.NewFormItem("Stream quotes", streaming),
+			widget.NewFormItem("Keep coin listings, hours", listingTTL),
+			widget.NewFormItem("DEX RPC", rpc),
+			widget.NewFormItem("DEX reference pair", reference),
+			widget.NewFormItem("DEX pairs", dexPairs),
+		)),
+		container.NewTabItem("Network", form(
+			widget.NewFormItem("Request timeout, seconds", timeout),
+			widget.NewFormItem("Proxy", proxy),
+			widget.NewFormItem("No proxy for", noProxy),
+			widget.NewFormItem("CA certificates", caFile),
+			widget.NewFormItem("User agent", userAgent),
+			widget.NewFormItem("Connect timeout, seconds", dialTimeout),
+			widget.NewFormItem("Max connections", maxConns),
+		)),
+		container.NewTabItem("Display", form(
+			widget.NewFormItem("Stale after, minutes", staleAfter),
+			widget.NewFormItem("Sparkline", sparkWindow),
+		)),
+		container.NewTabItem("History", form(
+			widget.NewFormItem("Keep quotes, days", retention[0]),
+			widget.NewFormItem("Keep hourly candles, days", retention[1]),
+			widget.NewFormItem("Keep daily candles, days", retention[2]),
+		)),
+	)
+
+	d := dialog.NewCustomConfirm(
 		"Settings",
 		"Save",
 		"Discard",
-		[]*widget.FormItem{
-			widget.NewFormItem("API Key", apiKey),
-			widget.NewFormItem("Refresh interval", interval),
-		},
+		tabs,
 		func(b bool) {
 			if !b {
 				return
 			}
-			a.apiKey = apiKey.Text
 			if interval.SelectedIndex() >= 0 {
 				a.interval = optionsInt[interval.SelectedIndex()]
 			}
+			maxDeviation, _ := strconv.ParseFloat(deviation.Text, 64)
+			reconnect := provider.Selected != a.provider || maxDeviation != a.maxDeviation || apiKey.Text != a.apiKey
+
+			dex := crypto.DEXConfig{
+				RPC:      strings.TrimSpace(rpc.Text),
+				Currency: a.dex.Currency,
+			}
+			if pairs, err := parsePairs(reference.Text); err == nil && len(pairs) > 0 {
+				dex.Reference = pairs[0]
+			}
+			dex.Pairs, _ = parsePairs(dexPairs.Text)
+			if !reflect.DeepEqual(dex, a.dex) {
+				reconnect = true
+			}
+			a.dex = dex
+			a.Lock()
+			a.cancelCallsLocked(false)
+			if n, err := strconv.Atoi(timeout.Text); err == nil && n > 0 {
+				a.requestTimeout = time.Duration(n) * time.Second
+			}
+			a.Unlock()
+
+			httpConfig := crypto.HTTPConfig{
+				Proxy:           strings.TrimSpace(proxy.Text),
+				NoProxy:         noProxy.Text,
+				CAFile:          strings.TrimSpace(caFile.Text),
+				ResponseTimeout: a.http.ResponseTimeout,
+				UserAgent:       userAgent.Text,
+			}
+			if n, err := strconv.Atoi(dialTimeout.Text); err == nil && n > 0 {
+				httpConfig.DialTimeout = time.Duration(n) * time.Second
+			}
+			if n, err := strconv.Atoi(maxConns.Text); err == nil && n >= 0 {
+				httpConfig.MaxConns = n
+			}
+			if err := crypto.SetHTTPConfig(httpConfig); err != nil {
+				dialog.ShowError(fmt.Errorf("Network settings were not changed: %v", err), a.window)
+			} else {
+				a.http = httpConfig
+			}
+			a.apiKey = apiKey.Text
+			a.provider = provider.Selected
+			a.maxDeviation = maxDeviation
+			a.streaming = streaming.Checked
+			if n, err := strconv.Atoi(budget.Text); err == nil && n > 0 {
+				a.creditBudget = n
+			}
+			a.adaptiveInterval = adaptive.Checked
+			if staleAfter.Text == "" {
+				a.staleAfter = 0
+			} else if n, err := strconv.Atoi(staleAfter.Text); err == nil && n >= 0 {
+				a.staleAfter = time.Duration(n) * time.Minute
+			}
+			if i := sparkWindow.SelectedIndex(); i >= 0 {
+				a.setSparklineWindow(sparklineOptionsDur[i])
+			}
+			a.Lock()
+			for i, d := range []*time.Duration{&a.retention.Raw, &a.retention.Hourly, &a.retention.Daily} {
+				if n, err := strconv.Atoi(retention[i].Text); err == nil && n >= 0 {
+					*d = time.Duration(n) * time.Hour * 24
+				}
+			}
+			a.Unlock()
+			if n, err := strconv.Atoi(listingTTL.Text); err == nil && n > 0 {
+				a.listingTTL = time.Duration(n) * time.Hour
+			}
 			a.saveSettings()
+			a.updateCredits()
+			if reconnect {
+				a.setFailoverEvent("")
+				a.connect()
+			} else {
+				a.restartStream()
+			}
+			a.applyCurrencies()
 			a.pbWidget.Refresh()
+			a.listWidget.Refresh()
 		},
 		a.window,
 	)
+	d.Resize(a.window.Canvas().Size())
+	d.Show()
 }
 
 func (a *App) updateCurrencies() {
-	if a.feed == nil {
+	feed := a.currentFeed()
+	if feed == nil {
 		return
 	}
-	a.currencyWidget.Options = a.feed.GetCurrencies()
+	currencies := feed.GetCurrencies()
+	a.currencyWidget.Options = currencies
 	a.currencyWidget.SetSelected(a.currency)
+
+	a.secondaryWidget.Options = append([]string{noCurrency}, currencies...)
+	if a.secondary == "" {
+		a.secondaryWidget.SetSelected(noCurrency)
+	} else {
+		a.secondaryWidget.SetSelected(a.secondary)
+	}
+}
+
+// applyCurrencies makes every coin display the selected currencies.
+func (a *App) applyCurrencies() {
+	a.Lock()
+	for i, cd := range a.coinData {
+		if cn, ok := cd.(*coin.CoinData); ok {
+			updated := cn.WithCurrencies(a.currency, a.secondary)
+			updated.Sparkline = a.sparkline(cn.Symbol, a.currency, updated.Quotes[a.currency].Price)
+			a.coinData[i] = updated
+		}
+	}
+	a.Unlock()
+	a.data.Reload()
+}
+
+// currencies returns the display currencies, primary first.
+func (a *App) currencies() []string {
+	if a.secondary == "" || a.secondary == a.currency {
+		return []string{a.currency}
+	}
+	return []string{a.currency, a.secondary}
 }
 
 func (a *App) updateQuotes() {
-	if a.feed == nil {
+	feed := a.currentFeed()
+	if feed == nil {
 		return
 	}
 
-	symbols := make([]string, 0, len(a.coinData))
+	a.Lock()
+	symbols := make([]crypto.Symbol, 0, len(a.coinData))
 	for _, cd := range a.coinData {
 		if s, ok := cd.(*coin.CoinData); ok {
-			symbols = append(symbols, s.Symbol.Symbol)
+			symbols = append(symbols, s.Symbol)
 		}
 	}
-	quotes, err := a.feed.GetQuotes(a.currency, symbols...)
-	if err != nil {
-		logger.Log.Error().Err(err).Msg("Could not get quotes")
+	a.Unlock()
+	used := a.creditsUsed()
+	var errs []string
+	for _, currency := range a.currencies() {
+		ctx, cancel := a.requestContext()
+		quotes, err := feed.GetQuotes(ctx, currency, symbols...)
+		cancel()
+		if err != nil {
+			logger.Log.Error().Err(err).Str("currency", currency).Msg("Could not get quotes")
+			if msg := errorMessage(err); !contains(errs, msg) {
+				errs = append(errs, msg)
+			}
+		}
+		for _, quote := range quotes {
+			a.updateQuote(currency, quote)
+		}
 	}
-
-	for _, quote := range quotes {
-		a.updateQuote(quote)
+	if spent := a.creditsUsed() - used; spent > 0 {
+		a.refreshCredits = spent
 	}
+	a.setStatus(strings.Join(errs, "; "))
 
 	a.lastUpdated = time.Now()
 }
 
-func (a *App) delSymbol(symbol string) {
+// coinSymbol returns the symbol of the watched coin that s denotes, or s if none does.
+func (a *App) coinSymbol(s crypto.Symbol) crypto.Symbol {
+	a.Lock()
+	defer a.Unlock()
+	for _, cd := range a.coinData {
+		if coin, ok := cd.(*coin.CoinData); ok && coin.Symbol.Is(s) {
+			return coin.Symbol
+		}
+	}
+	return s
+}
+
+func (a *App) delSymbol(symbol crypto.Symbol) {
+	defer a.restartStream()
 	defer a.data.Reload()
 	a.Lock()
 	defer a.Unlock()
@@ -200,7 +526,7 @@ func (a *App) delSymbol(symbol string) {
 
 	for idx, cd := range a.coinData {
 		if coin, ok := cd.(*coin.CoinData); ok {
-			if coin.Symbol.Symbol == symbol {
+			if coin.Symbol.Is(symbol) {
 				delList = append(delList, idx)
 			}
 		}
@@ -211,8 +537,14 @@ func (a *App) delSymbol(symbol string) {
 	}
 }
 
+func symbolOption(s crypto.Symbol) string {
+	return fmt.Sprintf("%s (%s)", s.Symbol, s.Name)
+}
+
+// lookupSymbol finds a coin by the ticker typed in by the user.
 func (a *App) lookupSymbol(symbol string) (crypto.Symbol, bool) {
-	if a.feed == nil {
+	feed := a.currentFeed()
+	if feed == nil {
 		return crypto.Symbol{}, false
 	}
 	parts := strings.Split(symbol, " ")
@@ -220,7 +552,7 @@ func (a *App) lookupSymbol(symbol string) (crypto.Symbol, bool) {
 		symbol = parts[0]
 	}
 
-	for _, s := range a.feed.GetSymbols() {
+	for _, s := range feed.GetSymbols() {
 		if s.Symbol == symbol {
 			return s, true
 		}
@@ -228,17 +560,17 @@ func (a *App) lookupSymbol(symbol string) (crypto.Symbol, bool) {
 	return crypto.Symbol{}, false
 }
 
-func (a *App) getSymbol(symbol string) (crypto.Symbol, bool) {
+func (a *App) getSymbol(symbol crypto.Symbol) (crypto.Symbol, bool) {
 	a.Lock()
 	defer a.Unlock()
 
 	return a.getSymbolLocked(symbol)
 }
 
-func (a *App) getSymbolLocked(symbol string) (crypto.Symbol, bool) {
+func (a *App) getSymbolLocked(symbol crypto.Symbol) (crypto.Symbol, bool) {
 	for _, cd := range a.coinData {
 		if coin, ok := cd.(*coin.CoinData); ok {
-			if coin.Symbol.Symbol == symbol {
+			if coin.Symbol.Is(symbol) {
 				return coin.Symbol, true
 			}
 		}
@@ -248,37 +580,57 @@ func (a *App) getSymbolLocked(symbol string) (crypto.Symbol, bool) {
 }
 
 func (a *App) addSymbol(symbol crypto.Symbol) {
-	var quotes []crypto.Quote
-	if a.feed != nil {
-		quotes, _ = a.feed.GetQuotes(a.currency, symbol.Symbol)
+	quotes := make(map[string]crypto.Quote)
+	if feed := a.currentFeed(); feed != nil {
+		for _, currency := range a.currencies() {
+			ctx, cancel := a.requestContext()
+			q, _ := feed.GetQuotes(ctx, currency, symbol)
+			cancel()
+			if len(q) > 0 {
+				quotes[currency] = q[0]
+			}
+		}
 	}
 
 	a.Lock()
 	defer a.Unlock()
 
-	if _, ok := a.getSymbolLocked(symbol.Symbol); ok {
+	if _, ok := a.getSymbolLocked(symbol); ok {
 		return
 	}
 
-	coin := coin.NewSymbol(symbol)
-	if quotes != nil {
-		coin = coin.UpdateQuote(quotes[0])
+	coin := coin.NewSymbol(symbol, a.currency, a.secondary)
+	for currency, q := range quotes {
+		if updated := coin.UpdateQuote(currency, q); updated != nil {
+			coin = updated
+		}
 	}
 
 	a.data.Append(coin)
 }
 
-func (a *App) updateQuote(quote crypto.Quote) {
+func (a *App) updateQuote(currency string, quote crypto.Quote) {
+	// A failover feed quotes coins listed by another provider, history and alerts are kept by the watched coin
+	symbol := a.coinSymbol(quote.Symbol)
+	recorded := quote
+	recorded.Symbol = symbol
+	a.recordQuote(currency, recorded)
+	a.checkAlert(currency, recorded)
+	spark := a.sparkline(symbol, currency, quote.Price)
+
 	a.Lock()
 	defer a.Unlock()
 	for i, cd := range a.coinData {
 		if coin, ok := cd.(*coin.CoinData); ok {
-			if coin.Symbol == quote.Symbol {
-				updatedCoin := coin.UpdateQuote(quote)
+			if coin.Symbol.Is(quote.Symbol) {
+				updatedCoin := coin.UpdateQuote(currency, quote)
 				if updatedCoin == nil {
 					logger.Log.Error().Str("coin", coin.Symbol.Symbol).Str("quote", quote.Symbol.Symbol).Msg("Failed to update")
 					continue
 				}
+				if currency == updatedCoin.Currency {
+					updatedCoin.Sparkline = spark
+				}
 				a.data.SetValue(i, updatedCoin)
 				break
 			}
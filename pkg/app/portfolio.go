@@ -0,0 +1,133 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+	"github.com/itohio/CoinWatcher/pkg/logger"
+)
+
+// Alert notifies once the price in Currency rises to Above or falls to Below. Zero disables a bound.
+type Alert struct {
+	Currency string  `json:"currency"`
+	Above    float64 `json:"above,omitempty"`
+	Below    float64 `json:"below,omitempty"`
+}
+
+// Portfolio holds amounts of coins and price alerts by coinKey.
+type Portfolio struct {
+	Holdings map[string]float64 `json:"holdings,omitempty"`
+	Alerts   map[string]Alert   `json:"alerts,omitempty"`
+}
+
+// coinKey identifies a coin in the portfolio. Coins without a provider id are keyed by ticker.
+func coinKey(s crypto.Symbol) string {
+	if s.Provider == "" || s.Id == 0 {
+		return s.Symbol
+	}
+	return fmt.Sprintf("%s@%s-%d", s.Symbol, s.Provider, s.Id)
+}
+
+func (a *App) loadPortfolio() {
+	reader, err := a.reader("portfolio.json")
+	if err != nil {
+		return
+	}
+	defer reader.Close()
+
+	var portfolio Portfolio
+	if err := json.NewDecoder(reader).Decode(&portfolio); err != nil {
+		logger.Log.Error().Err(err).Msg("Could not decode portfolio")
+		return
+	}
+	a.Lock()
+	a.portfolio = portfolio
+	a.Unlock()
+}
+
+func (a *App) savePortfolio() {
+	writer, err := a.writer("portfolio.json")
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Could not get portfolio writer")
+		return
+	}
+	defer writer.Close()
+
+	a.Lock()
+	data, err := json.Marshal(&a.portfolio)
+	a.Unlock()
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Could not marshal portfolio")
+		return
+	}
+
+	if _, err := writer.Write(data); err != nil {
+		logger.Log.Error().Err(err).Msg("Could not write portfolio")
+	}
+}
+
+// holding returns the amount held and the alert of a coin.
+func (a *App) holding(symbol crypto.Symbol) (float64, Alert, bool) {
+	a.Lock()
+	defer a.Unlock()
+	key := coinKey(symbol)
+	alert, ok := a.portfolio.Alerts[key]
+	return a.portfolio.Holdings[key], alert, ok
+}
+
+// setHolding sets the amount held of a coin, zero removes it.
+func (a *App) setHolding(symbol crypto.Symbol, amount float64) {
+	a.Lock()
+	if a.portfolio.Holdings == nil {
+		a.portfolio.Holdings = make(map[string]float64)
+	}
+	if amount > 0 {
+		a.portfolio.Holdings[coinKey(symbol)] = amount
+	} else {
+		delete(a.portfolio.Holdings, coinKey(symbol))
+	}
+	a.Unlock()
+	a.savePortfolio()
+}
+
+// setAlert sets the price alert of a coin, an alert without bounds removes it.
+func (a *App) setAlert(symbol crypto.Symbol, alert Alert) {
+	a.Lock()
+	if a.portfolio.Alerts == nil {
+		a.portfolio.Alerts = make(map[string]Alert)
+	}
+	if alert.Above > 0 || alert.Below > 0 {
+		a.portfolio.Alerts[coinKey(symbol)] = alert
+	} else {
+		delete(a.portfolio.Alerts, coinKey(symbol))
+	}
+	a.Unlock()
+	a.savePortfolio()
+}
+
+// checkAlert notifies about a quote of a watched coin reaching an alert bound. The bound is removed after notifying.
+func (a *App) checkAlert(currency string, quote crypto.Quote) {
+	a.Lock()
+	alert, ok := a.portfolio.Alerts[coinKey(quote.Symbol)]
+	a.Unlock()
+	if !ok || alert.Currency != currency || quote.Price <= 0 {
+		return
+	}
+
+	var msg string
+	switch {
+	case alert.Above > 0 && quote.Price >= alert.Above:
+		msg = fmt.Sprintf("%s rose to %v %s", quote.Symbol.Symbol, quote.Price, currency)
+		alert.Above = 0
+	case alert.Below > 0 && quote.Price <= alert.Below:
+		msg = fmt.Sprintf("%s fell to %v %s", quote.Symbol.Symbol, quote.Price, currency)
+		alert.Below = 0
+	default:
+		return
+	}
+	logger.Log.Info().Str("symbol", quote.Symbol.Symbol).Float64("price", quote.Price).Msg("Price alert")
+	a.setAlert(quote.Symbol, alert)
+	a.app.SendNotification(fyne.NewNotification("Price alert", msg))
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+)
+
+func TestCoinKey(t *testing.T) {
+	for _, tc := range []struct {
+		s    crypto.Symbol
+		want string
+	}{
+		{btc, "BTC@coingecko-1"},
+		{cmcBTC, "BTC@CoinMarketCap-1"},
+		{crypto.Symbol{Symbol: "BTC", Provider: "coingecko"}, "BTC"},
+		{crypto.Symbol{Symbol: "BTC", Id: 1}, "BTC"},
+	} {
+		if got := coinKey(tc.s); got != tc.want {
+			t.Errorf("%+v: got %q, want %q", tc.s, got, tc.want)
+		}
+	}
+}
+
+func TestCheckAlert(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		symbol   crypto.Symbol
+		currency string
+		price    float64
+		msg      string
+		want     Alert
+		removed  bool
+	}{
+		{"between", btc, "USD", 150, "", Alert{Currency: "USD", Above: 200, Below: 100}, false},
+		{"other currency", btc, "EUR", 250, "", Alert{Currency: "USD", Above: 200, Below: 100}, false},
+		{"other coin", cmcBTC, "USD", 250, "", Alert{Currency: "USD", Above: 200, Below: 100}, false},
+		{"no price", btc, "USD", 0, "", Alert{Currency: "USD", Above: 200, Below: 100}, false},
+		{"rose", btc, "USD", 200, "BTC rose to 200 USD", Alert{Currency: "USD", Below: 100}, false},
+		{"fell", btc, "USD", 90, "BTC fell to 90 USD", Alert{Currency: "USD", Above: 200}, false},
+	} {
+		a := newTestApp(t)
+		a.portfolio.Alerts = map[string]Alert{coinKey(btc): {Currency: "USD", Above: 200, Below: 100}}
+
+		var n *fyne.Notification
+		if tc.msg != "" {
+			n = fyne.NewNotification("Price alert", tc.msg)
+		}
+		test.AssertNotificationSent(t, n, func() {
+			a.checkAlert(tc.currency, crypto.Quote{Symbol: tc.symbol, Price: tc.price})
+		})
+		if _, alert, _ := a.holding(btc); alert != tc.want {
+			t.Errorf("%s: alert is %+v, want %+v", tc.name, alert, tc.want)
+		}
+	}
+
+	// An alert is removed once both bounds are reached
+	a := newTestApp(t)
+	a.portfolio.Alerts = map[string]Alert{coinKey(btc): {Currency: "USD", Above: 200}}
+	a.checkAlert("USD", crypto.Quote{Symbol: btc, Price: 300})
+	if _, _, ok := a.holding(btc); ok {
+		t.Error("alert without bounds is kept")
+	}
+}
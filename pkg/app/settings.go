@@ -9,21 +9,40 @@ import (
 
 	"fyne.io/fyne/v2/storage"
 	"github.com/itohio/CoinWatcher/pkg/crypto"
+	"github.com/itohio/CoinWatcher/pkg/history"
 	"github.com/itohio/CoinWatcher/pkg/logger"
 )
 
 type Settings struct {
-	APIKey   string        `json:"coinmarketcap_api_key"`
-	Currency string        `json:"currency"`
-	Interval time.Duration `json:"refresh_interval"`
+	APIKey       string            `json:"coinmarketcap_api_key"`
+	Provider     string            `json:"provider"`
+	MaxDeviation float64           `json:"max_deviation"`
+	Streaming    bool              `json:"streaming"`
+	Currency     string            `json:"currency"`
+	Secondary    string            `json:"secondary_currency"`
+	Interval     time.Duration     `json:"refresh_interval"`
+	CreditBudget int               `json:"credit_budget"`
+	Adaptive     bool              `json:"adaptive_interval"`
+	ListingTTL   time.Duration     `json:"listing_ttl"`
+	Timeout      time.Duration     `json:"request_timeout"`
+	HTTP         crypto.HTTPConfig `json:"http"`
+	StaleAfter   time.Duration     `json:"stale_after"`
+	DEX          crypto.DEXConfig  `json:"dex"`
+	History      history.Retention `json:"history"`
+	Sparkline    time.Duration     `json:"sparkline_window"`
 }
 
 func (a *App) defaultSettings() {
 	logger.Log.Info().Msg("Loading default settings")
 	a.apiKey = os.Getenv("COINWATCHER_KEY")
-	a.feed = crypto.NewCMC(a.apiKey, a)
-	a.currency = a.feed.GetCurrencies()[0]
+	a.provider = providerCMC
+	a.maxDeviation = defaultMaxDeviation
 	a.interval = time.Hour * 3
+	a.creditBudget = defaultCreditBudget
+	a.listingTTL = defaultListingTTL
+	a.requestTimeout = defaultRequestTimeout
+	a.http = crypto.DefaultHTTPConfig
+	a.retention = history.DefaultRetention
 
 	a.saveSettings()
 }
@@ -37,7 +56,10 @@ func (a *App) loadSettings() {
 	}
 	defer reader.Close()
 
-	var settings Settings
+	// Zero deviation disables outlier rejection, so the default applies only if the key is missing
+	settings := Settings{
+		MaxDeviation: defaultMaxDeviation,
+	}
 
 	err = json.NewDecoder(reader).Decode(&settings)
 	if err != nil {
@@ -50,17 +72,60 @@ func (a *App) loadSettings() {
 		settings.APIKey = os.Getenv("COINWATCHER_KEY")
 	}
 
-	a.feed = crypto.NewCMC(settings.APIKey, a)
 	a.currency = settings.Currency
+	a.secondary = settings.Secondary
 	a.apiKey = settings.APIKey
+	a.provider = settings.Provider
+	a.maxDeviation = settings.MaxDeviation
+	a.streaming = settings.Streaming
 	a.interval = settings.Interval
+	a.creditBudget = settings.CreditBudget
+	a.adaptiveInterval = settings.Adaptive
+	a.listingTTL = settings.ListingTTL
+	a.requestTimeout = settings.Timeout
+	a.staleAfter = settings.StaleAfter
+	a.dex = settings.DEX
+	a.sparkWindow = settings.Sparkline
+	a.retention = settings.History
+	if a.retention == (history.Retention{}) {
+		a.retention = history.DefaultRetention
+	}
+	a.http = settings.HTTP
+	if a.http == (crypto.HTTPConfig{}) {
+		a.http = crypto.DefaultHTTPConfig
+	}
+	if err := crypto.SetHTTPConfig(a.http); err != nil {
+		logger.Log.Error().Err(err).Msg("Could not configure HTTP client")
+	}
+	if a.creditBudget <= 0 {
+		a.creditBudget = defaultCreditBudget
+	}
+	if a.listingTTL <= 0 {
+		a.listingTTL = defaultListingTTL
+	}
+	if a.requestTimeout <= 0 {
+		a.requestTimeout = defaultRequestTimeout
+	}
 }
 
 func (a *App) saveSettings() {
 	settings := Settings{
-		Currency: a.currency,
-		Interval: a.interval,
-		APIKey:   a.apiKey,
+		Currency:     a.currency,
+		Secondary:    a.secondary,
+		Interval:     a.interval,
+		APIKey:       a.apiKey,
+		Provider:     a.provider,
+		MaxDeviation: a.maxDeviation,
+		Streaming:    a.streaming,
+		CreditBudget: a.creditBudget,
+		Adaptive:     a.adaptiveInterval,
+		ListingTTL:   a.listingTTL,
+		Timeout:      a.requestTimeout,
+		HTTP:         a.http,
+		StaleAfter:   a.staleAfter,
+		DEX:          a.dex,
+		History:      a.retention,
+		Sparkline:    a.sparkWindow,
 	}
 
 	writer, err := a.writer("config.json")
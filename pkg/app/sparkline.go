@@ -0,0 +1,92 @@
+package app
+
+import (
+	"time"
+
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+	"github.com/itohio/CoinWatcher/pkg/history"
+	"github.com/itohio/CoinWatcher/pkg/logger"
+)
+
+const (
+	// sparklinePoints is the number of prices drawn in a sparkline
+	sparklinePoints   = 48
+	defaultSparkline  = time.Hour * 24
+	sparklineDisabled = -1
+)
+
+// Sparkline windows offered in settings
+var (
+	sparklineOptions    = []string{"Off", "24 hours", "7 days", "30 days"}
+	sparklineOptionsDur = []time.Duration{sparklineDisabled, time.Hour * 24, time.Hour * 24 * 7, time.Hour * 24 * 30}
+)
+
+type sparkline struct {
+	values  []float64
+	updated time.Time
+}
+
+// sparklineWindow returns the configured window, or zero if sparklines are off.
+func (a *App) sparklineWindow() time.Duration {
+	a.sparkLock.Lock()
+	defer a.sparkLock.Unlock()
+	switch {
+	case a.sparkWindow < 0:
+		return 0
+	case a.sparkWindow == 0:
+		return defaultSparkline
+	}
+	return a.sparkWindow
+}
+
+// setSparklineWindow changes the window and drops cached sparklines.
+func (a *App) setSparklineWindow(d time.Duration) {
+	a.sparkLock.Lock()
+	defer a.sparkLock.Unlock()
+	if d != a.sparkWindow {
+		a.sparkWindow = d
+		a.sparks = nil
+	}
+}
+
+// sparkline returns recent prices of a coin from history ending with price.
+// History is read at most once per sparkline step, in between only the last price is updated.
+func (a *App) sparkline(symbol crypto.Symbol, currency string, price float64) []float64 {
+	window := a.sparklineWindow()
+	if a.history == nil || window <= 0 {
+		return nil
+	}
+	step := window / sparklinePoints
+	// Coins sharing a ticker have separate sparklines
+	key := history.SeriesOf(symbol, currency)
+	now := time.Now()
+
+	a.sparkLock.Lock()
+	cached, ok := a.sparks[key]
+	a.sparkLock.Unlock()
+
+	if !ok || now.Sub(cached.updated) >= step {
+		points, err := a.history.Candles(key, now.Add(-window), now, step)
+		if err != nil {
+			logger.Log.Error().Err(err).Str("symbol", symbol.Symbol).Msg("Could not read sparkline")
+			return nil
+		}
+		cached = sparkline{
+			values:  make([]float64, len(points)),
+			updated: now,
+		}
+		for i, p := range points {
+			cached.values[i] = p.Price
+		}
+	} else if n := len(cached.values); n > 0 && price > 0 {
+		cached.values = append(cached.values[:n-1:n-1], price)
+	}
+
+	a.sparkLock.Lock()
+	if a.sparks == nil {
+		a.sparks = make(map[history.Series]sparkline)
+	}
+	a.sparks[key] = cached
+	a.sparkLock.Unlock()
+	return cached.values
+}
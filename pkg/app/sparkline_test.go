@@ -0,0 +1,73 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/itohio/CoinWatcher/pkg/history"
+)
+
+func TestSparklineWindow(t *testing.T) {
+	for _, tc := range []struct {
+		window time.Duration
+		want   time.Duration
+	}{
+		{sparklineDisabled, 0},
+		{0, defaultSparkline},
+		{time.Hour * 24 * 7, time.Hour * 24 * 7},
+	} {
+		a := &App{sparkWindow: tc.window}
+		if got := a.sparklineWindow(); got != tc.want {
+			t.Errorf("%v: got %v, want %v", tc.window, got, tc.want)
+		}
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	store, err := history.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &App{history: store}
+	// Points fall into separate candles in both windows, later points into the last one
+	t0 := time.Now().Add(-time.Minute).Truncate(time.Hour * 24 * 7 / sparklinePoints)
+	point := func(t time.Time, price float64) history.Point {
+		return history.Point{Time: t, Open: price, High: price, Low: price, Price: price}
+	}
+	if err := store.Append(history.SeriesOf(btc, "USD"), point(t0.Add(-time.Hour*7), 1), point(t0, 2)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		update func()
+		price  float64
+		want   []float64
+	}{
+		{"read", nil, 3, []float64{1, 2}},
+		{"cached", func() {
+			store.Append(history.SeriesOf(btc, "USD"), point(t0.Add(time.Second), 10))
+		}, 3, []float64{1, 3}},
+		{"no price", nil, 0, []float64{1, 3}},
+		{"window changed", func() {
+			a.setSparklineWindow(time.Hour * 24 * 7)
+		}, 3, []float64{1, 10}},
+		{"off", func() {
+			a.setSparklineWindow(sparklineDisabled)
+		}, 3, nil},
+	} {
+		if tc.update != nil {
+			tc.update()
+		}
+		if got := a.sparkline(btc, "usd", tc.price); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	// Coins sharing a ticker have separate sparklines
+	a.setSparklineWindow(0)
+	if got := a.sparkline(cmcBTC, "USD", 3); len(got) != 0 {
+		t.Errorf("got %v for a coin without history", got)
+	}
+}
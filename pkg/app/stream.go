@@ -0,0 +1,53 @@
+package app
+
+import (
+	"context"
+
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+	"github.com/itohio/CoinWatcher/pkg/logger"
+	"github.com/itohio/CoinWatcher/pkg/widgets/coin"
+)
+
+// restartStream resubscribes to streamed quotes of the current coin list if the feed supports it.
+func (a *App) restartStream() {
+	a.Lock()
+	if a.streamCancel != nil {
+		a.streamCancel()
+		a.streamCancel = nil
+	}
+	streamer, ok := a.feed.(crypto.Streamer)
+	if !a.streaming || !ok {
+		a.Unlock()
+		return
+	}
+
+	symbols := make([]crypto.Symbol, 0, len(a.coinData))
+	for _, cd := range a.coinData {
+		if s, ok := cd.(*coin.CoinData); ok {
+			symbols = append(symbols, s.Symbol)
+		}
+	}
+	ctx, cancel := context.WithCancel(a.feedCtx)
+	a.streamCancel = cancel
+	currencies := a.currencies()
+	a.Unlock()
+
+	if len(symbols) == 0 {
+		return
+	}
+
+	for _, currency := range currencies {
+		quotes, err := streamer.Subscribe(ctx, currency, symbols...)
+		if err != nil {
+			logger.Log.Error().Err(err).Str("currency", currency).Msg("Could not subscribe to quotes")
+			a.setStatus(errorMessage(err))
+			continue
+		}
+
+		go func(currency string) {
+			for quote := range quotes {
+				a.updateQuote(currency, quote)
+			}
+		}(currency)
+	}
+}
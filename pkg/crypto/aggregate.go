@@ -0,0 +1,248 @@
+package crypto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itohio/CoinWatcher/pkg/logger"
+)
+
+type aggregate struct {
+	providers []Crypto
+	deviation float64
+}
+
+var (
+	_ Crypto    = &aggregate{}
+	_ Describer = &aggregate{}
+)
+
+// NewAggregate combines quotes from several providers. Quotes that deviate from the median price
+// by more than deviation percent are dropped. Symbols without a price most providers agree on are
+// left out and reported with ErrNoConsensus.
+func NewAggregate(deviation float64, providers ...Crypto) *aggregate {
+	return &aggregate{
+		providers: providers,
+		deviation: deviation,
+	}
+}
+
+func (c *aggregate) Name() string {
+	names := make([]string, len(c.providers))
+	for i, p := range c.providers {
+		names[i] = p.Name()
+	}
+	return fmt.Sprintf("Aggregate(%s)", strings.Join(names, ","))
+}
+
+func (c *aggregate) GetSymbols() []Symbol {
+	var symbols []Symbol
+	seen := make(map[string]struct{})
+	for _, p := range c.providers {
+		for _, s := range p.GetSymbols() {
+			if _, ok := seen[s.Symbol]; ok {
+				continue
+			}
+			seen[s.Symbol] = struct{}{}
+			symbols = append(symbols, s)
+		}
+	}
+	return symbols
+}
+
+func (c *aggregate) GetCurrencies() []string {
+	var currencies []string
+	seen := make(map[string]struct{})
+	for _, p := range c.providers {
+		for _, q := range p.GetCurrencies() {
+			if _, ok := seen[q]; ok {
+				continue
+			}
+			seen[q] = struct{}{}
+			currencies = append(currencies, q)
+		}
+	}
+	sort.Sort(sort.StringSlice(currencies))
+	return currencies
+}
+
+func (c *aggregate) FindSymbol(symbol string) (Symbol, bool) {
+	for _, p := range c.providers {
+		if s, ok := p.FindSymbol(symbol); ok {
+			return s, true
+		}
+	}
+	return Symbol{}, false
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	n := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[n-1] + sorted[n]) / 2
+	}
+	return sorted[n]
+}
+
+// medianOf returns the median of the non zero values picked from quotes.
+func medianOf(quotes []Quote, pick func(q *Quote) float64) float64 {
+	values := make([]float64, 0, len(quotes))
+	for i := range quotes {
+		if v := pick(&quotes[i]); v != 0 {
+			values = append(values, v)
+		}
+	}
+	return median(values)
+}
+
+// merge combines quotes of symbol from several providers. It fails if every quote deviates from the median,
+// which happens when two providers disagree and there is no majority to tell which one is wrong.
+func (c *aggregate) merge(symbol Symbol, quotes []Quote) (Quote, bool) {
+	prices := make([]float64, len(quotes))
+	for i, q := range quotes {
+		prices[i] = q.Price
+	}
+	m := median(prices)
+
+	accepted := make([]Quote, 0, len(quotes))
+	for _, q := range quotes {
+		if dev := math.Abs(q.Price-m) / m * 100; c.deviation > 0 && dev > c.deviation {
+			logger.Log.Warn().Str("symbol", symbol.Symbol).Strs("source", q.Sources).Float64("price", q.Price).Float64("median", m).Float64("deviation", dev).Msg("Dropped outlier quote")
+			continue
+		}
+		accepted = append(accepted, q)
+	}
+	if len(accepted) == 0 {
+		return Quote{}, false
+	}
+
+	ret := Quote{
+		Symbol: symbol,
+	}
+	ret.Price = medianOf(accepted, func(q *Quote) float64 { return q.Price })
+	ret.MarketCap = medianOf(accepted, func(q *Quote) float64 { return q.MarketCap })
+	ret.PercentChange1H = medianOf(accepted, func(q *Quote) float64 { return q.PercentChange1H })
+	ret.PercentChange24H = medianOf(accepted, func(q *Quote) float64 { return q.PercentChange24H })
+	ret.PercentChange7D = medianOf(accepted, func(q *Quote) float64 { return q.PercentChange7D })
+	ret.PercentChange30D = medianOf(accepted, func(q *Quote) float64 { return q.PercentChange30D })
+	// Aggregators report volumes of the same markets, so they are not added up
+	ret.Volume24H = medianOf(accepted, func(q *Quote) float64 { return q.Volume24H })
+	ret.Volume7D = medianOf(accepted, func(q *Quote) float64 { return q.Volume7D })
+	ret.Volume30D = medianOf(accepted, func(q *Quote) float64 { return q.Volume30D })
+	ret.Volume24Hbase = medianOf(accepted, func(q *Quote) float64 { return q.Volume24Hbase })
+	ret.Volume24Hquote = medianOf(accepted, func(q *Quote) float64 { return q.Volume24Hquote })
+
+	for _, q := range accepted {
+		if q.LastUpdated.After(ret.LastUpdated) {
+			ret.LastUpdated = q.LastUpdated
+		}
+		ret.Sources = append(ret.Sources, q.Sources...)
+	}
+
+	return ret, true
+}
+
+func (c *aggregate) GetQuotes(ctx context.Context, currency string, symbol ...Symbol) ([]Quote, error) {
+	var (
+		wg     sync.WaitGroup
+		lock   sync.Mutex
+		errs   providerErrors
+		quotes []Quote
+	)
+
+	for _, p := range c.providers {
+		wg.Add(1)
+		go func(p Crypto) {
+			defer wg.Done()
+			qts, err := p.GetQuotes(ctx, currency, symbol...)
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+				return
+			}
+			for _, q := range qts {
+				if q.Price <= 0 {
+					continue
+				}
+				if len(q.Sources) == 0 {
+					q.Sources = []string{p.Name()}
+				}
+				quotes = append(quotes, q)
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	if len(errs) == len(c.providers) && len(errs) > 0 {
+		return nil, errs
+	}
+	for _, err := range errs {
+		// Providers list different coins
+		if errors.Is(err, ErrUnknownSymbol) {
+			continue
+		}
+		logger.Log.Error().Err(err).Msg("Provider failed")
+	}
+
+	ret := make([]Quote, 0, len(symbol))
+	var disagree []string
+	for _, s := range symbol {
+		var qts []Quote
+		for _, q := range quotes {
+			if s.Is(q.Symbol) {
+				qts = append(qts, q)
+			}
+		}
+		if len(qts) == 0 {
+			continue
+		}
+		if s.Name == "" {
+			s = qts[0].Symbol
+		}
+		q, ok := c.merge(s, qts)
+		if !ok {
+			disagree = append(disagree, s.Symbol)
+			continue
+		}
+		ret = append(ret, q)
+	}
+
+	if len(disagree) > 0 {
+		return ret, fmt.Errorf("%w: %s", ErrNoConsensus, strings.Join(disagree, ","))
+	}
+	return ret, nil
+}
+
+func (c *aggregate) GetOHLCV(ctx context.Context, currency string, interval time.Duration, start, end time.Time, symbol ...Symbol) ([]Ohlcv, error) {
+	var errs providerErrors
+	for _, p := range c.providers {
+		ret, err := p.GetOHLCV(ctx, currency, interval, start, end, symbol...)
+		if err == nil && len(ret) > 0 {
+			return ret, nil
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return nil, nil
+}
+
+func (c *aggregate) GetInfo(ctx context.Context, symbol Symbol) (Info, error) {
+	return describe(ctx, symbol, c.providers...)
+}
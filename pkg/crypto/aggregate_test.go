@@ -0,0 +1,134 @@
+package crypto
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMedian(t *testing.T) {
+	for _, tc := range []struct {
+		values []float64
+		want   float64
+	}{
+		{nil, 0},
+		{[]float64{3}, 3},
+		{[]float64{3, 1}, 2},
+		{[]float64{5, 1, 3}, 3},
+		{[]float64{4, 1, 3, 100}, 3.5},
+	} {
+		if got := median(tc.values); got != tc.want {
+			t.Errorf("median(%v) = %v, want %v", tc.values, got, tc.want)
+		}
+	}
+
+	quotes := []Quote{{MarketCap: 10}, {MarketCap: 0}, {MarketCap: 30}}
+	if got := medianOf(quotes, func(q *Quote) float64 { return q.MarketCap }); got != 20 {
+		t.Errorf("medianOf skips zeroes: got %v, want 20", got)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	t0 := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	quote := func(source string, price float64) Quote {
+		return Quote{Price: price, Volume24H: price * 100, MarketCap: price * 10, LastUpdated: t0, Sources: []string{source}}
+	}
+
+	for _, tc := range []struct {
+		name      string
+		deviation float64
+		quotes    []Quote
+		ok        bool
+		price     float64
+		sources   string
+	}{
+		{"single", 5, []Quote{quote("a", 100)}, true, 100, "a"},
+		{"agree", 5, []Quote{quote("a", 100), quote("b", 102)}, true, 101, "a,b"},
+		{"outlier", 5, []Quote{quote("a", 100), quote("b", 101), quote("c", 150)}, true, 100.5, "a,b"},
+		{"outliers both ways", 5, []Quote{quote("a", 50), quote("b", 100), quote("c", 101), quote("d", 99), quote("e", 200)}, true, 100, "b,c,d"},
+		{"disabled", 0, []Quote{quote("a", 100), quote("b", 101), quote("c", 150)}, true, 101, "a,b,c"},
+		{"two disagree", 5, []Quote{quote("a", 100), quote("b", 150)}, false, 0, ""},
+		{"even split", 5, []Quote{quote("a", 100), quote("b", 100), quote("c", 150), quote("d", 150)}, false, 0, ""},
+	} {
+		c := NewAggregate(tc.deviation)
+		got, ok := c.merge(Symbol{Symbol: "BTC"}, tc.quotes)
+		if ok != tc.ok {
+			t.Errorf("%s: ok %v, want %v", tc.name, ok, tc.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if got.Price != tc.price {
+			t.Errorf("%s: price %v, want %v", tc.name, got.Price, tc.price)
+		}
+		if sources := strings.Join(got.Sources, ","); sources != tc.sources {
+			t.Errorf("%s: sources %s, want %s", tc.name, sources, tc.sources)
+		}
+		// Providers report volumes of the same markets
+		if got.Volume24H != tc.price*100 {
+			t.Errorf("%s: volume %v, want %v", tc.name, got.Volume24H, tc.price*100)
+		}
+		if !got.LastUpdated.Equal(t0) || got.Symbol.Symbol != "BTC" {
+			t.Errorf("%s: unexpected quote %+v", tc.name, got)
+		}
+	}
+}
+
+func TestAggregateQuotes(t *testing.T) {
+	feed := func(name string, prices map[string]float64) *testFeed {
+		f := &testFeed{name: name, currencies: []string{"USD"}, quotes: map[string][]Quote{}}
+		for ticker, price := range prices {
+			f.quotes["USD"] = append(f.quotes["USD"], Quote{Symbol: Symbol{Provider: name, Symbol: ticker, Name: ticker}, Price: price})
+		}
+		return f
+	}
+	c := NewAggregate(5,
+		feed("a", map[string]float64{"BTC": 100, "ETH": 10}),
+		feed("b", map[string]float64{"BTC": 102, "ETH": 20}),
+		feed("c", map[string]float64{"BTC": 500}),
+	)
+
+	quotes, err := c.GetQuotes(context.Background(), "USD", Symbol{Symbol: "BTC"}, Symbol{Symbol: "ETH"})
+	if !errors.Is(err, ErrNoConsensus) || !strings.Contains(err.Error(), "ETH") {
+		t.Fatalf("expected ETH to have no consensus, got %v", err)
+	}
+	if len(quotes) != 1 || quotes[0].Symbol.Symbol != "BTC" || quotes[0].Price != 101 {
+		t.Fatalf("unexpected quotes %+v", quotes)
+	}
+	for _, q := range quotes {
+		if q.Price == 0 || len(q.Sources) == 0 {
+			t.Fatalf("quote without a price or sources %+v", q)
+		}
+	}
+}
+
+func TestAggregateErrors(t *testing.T) {
+	limited := &testFeed{name: "a", currencies: []string{"USD"}, err: &RateLimitError{RetryAfter: time.Minute, Err: errors.New("429")}}
+	unauthorized := &testFeed{name: "b", currencies: []string{"USD"}, err: ErrUnauthorized}
+	c := NewAggregate(5, limited, unauthorized)
+
+	_, err := c.GetQuotes(context.Background(), "USD", Symbol{Symbol: "BTC"})
+	if !errors.Is(err, ErrRateLimited) || !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("provider errors are lost: %v", err)
+	}
+	if after, ok := RetryAfter(err); !ok || after != time.Minute {
+		t.Fatalf("retry delay is lost: %v %v", after, ok)
+	}
+	if !strings.Contains(err.Error(), "a: ") || !strings.Contains(err.Error(), "b: ") {
+		t.Fatalf("providers are not named: %v", err)
+	}
+
+	_, err = c.GetOHLCV(context.Background(), "USD", time.Hour, time.Now().Add(-time.Hour), time.Now(), Symbol{Symbol: "BTC"})
+	if !errors.Is(err, ErrRateLimited) || !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("provider errors are lost: %v", err)
+	}
+
+	// One working provider is enough
+	c = NewAggregate(5, limited, newUSDFeed())
+	if quotes, err := c.GetQuotes(context.Background(), "USD", Symbol{Symbol: "BTC"}); err != nil || len(quotes) != 1 {
+		t.Fatalf("unexpected result %v %v", quotes, err)
+	}
+}
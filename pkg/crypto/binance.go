@@ -0,0 +1,318 @@
+package crypto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/itohio/CoinWatcher/pkg/logger"
+)
+
+const (
+	BinanceURL       = "https://api.binance.com"
+	BinanceStreamURL = "wss://stream.binance.com:9443/ws"
+	bnName           = "Binance"
+)
+
+type binance struct {
+	url        string
+	streamURL  string
+	symbols    []Symbol
+	pairs      map[string]map[string]string
+	currencies []string
+	iconCache  Cache
+}
+
+var (
+	_ Crypto   = &binance{}
+	_ Streamer = &binance{}
+)
+
+type bnExchangeInfo struct {
+	Symbols []struct {
+		Symbol     string `json:"symbol"`
+		Status     string `json:"status"`
+		BaseAsset  string `json:"baseAsset"`
+		QuoteAsset string `json:"quoteAsset"`
+	} `json:"symbols"`
+}
+
+type bnTicker struct {
+	Symbol             string  `json:"symbol"`
+	PriceChangePercent float64 `json:"priceChangePercent,string"`
+	LastPrice          float64 `json:"lastPrice,string"`
+	Volume             float64 `json:"volume,string"`
+	QuoteVolume        float64 `json:"quoteVolume,string"`
+	CloseTime          int64   `json:"closeTime"`
+}
+
+var bnInvalidSymbol = regexp.MustCompile(`"code":\s*-1121\b`)
+
+// bnError classifies Binance errors. Binance bans clients that keep calling after 429 with 418,
+// unknown pairs are reported with error code -1121.
+func bnError(url string, response *http.Response, body []byte) error {
+	switch {
+	case response.StatusCode == http.StatusTeapot:
+		return &RateLimitError{
+			RetryAfter: parseRetryAfter(response.Header.Get("Retry-After")),
+			Err:        httpError(url, response, body),
+		}
+	case bnInvalidSymbol.Match(body):
+		return fmt.Errorf("%w: %v", ErrUnknownSymbol, httpError(url, response, body))
+	}
+	return nil
+}
+
+// NewBinance creates a feed from Binance public market data. Empty baseURL means the public Binance API,
+// otherwise the ticker stream is expected at the /ws path of the same host.
+func NewBinance(ctx context.Context, baseURL string, iconCache Cache) (*binance, error) {
+	streamURL := BinanceStreamURL
+	if baseURL == "" {
+		baseURL = BinanceURL
+	} else {
+		streamURL = "ws" + strings.TrimPrefix(strings.TrimSuffix(baseURL, "/"), "http") + "/ws"
+	}
+	ret := &binance{
+		url:       strings.TrimSuffix(baseURL, "/"),
+		streamURL: streamURL,
+		pairs:     make(map[string]map[string]string),
+		iconCache: iconCache,
+	}
+
+	var info bnExchangeInfo
+	if err := getJSON(ctx, ret.url+"/api/v3/exchangeInfo", &info, bnError); err != nil {
+		return nil, fmt.Errorf("Could not get exchange info: %v", err)
+	}
+
+	currencies := make(map[string]struct{})
+	for _, s := range info.Symbols {
+		if s.Status != "TRADING" {
+			continue
+		}
+		quotes, ok := ret.pairs[s.BaseAsset]
+		if !ok {
+			quotes = make(map[string]string)
+			ret.pairs[s.BaseAsset] = quotes
+			ret.symbols = append(ret.symbols, Symbol{
+				Id:        idFromString(s.BaseAsset),
+				Provider:  bnName,
+				Name:      s.BaseAsset,
+				Symbol:    s.BaseAsset,
+				iconCache: iconCache,
+			})
+		}
+		quotes[s.QuoteAsset] = s.Symbol
+		currencies[s.QuoteAsset] = struct{}{}
+	}
+
+	ret.currencies = make([]string, 0, len(currencies))
+	for q := range currencies {
+		ret.currencies = append(ret.currencies, q)
+	}
+	sort.Sort(sort.StringSlice(ret.currencies))
+
+	logger.Log.Debug().Int("symbols", len(ret.symbols)).Int("currencies", len(ret.currencies)).Msg("Fetched Binance symbols")
+
+	return ret, nil
+}
+
+func (c *binance) Name() string {
+	return bnName
+}
+
+func (c *binance) GetSymbols() []Symbol {
+	return c.symbols
+}
+
+func (c *binance) GetCurrencies() []string {
+	return c.currencies
+}
+
+func (c *binance) FindSymbol(symbol string) (Symbol, bool) {
+	for _, s := range c.symbols {
+		if s.Symbol == symbol {
+			return s, true
+		}
+	}
+	return Symbol{}, false
+}
+
+// pair resolves symbol and returns the exchange pair name trading it against currency.
+func (c *binance) pair(currency string, symbol Symbol) (Symbol, string, bool) {
+	sym, ok := lookup(c.symbols, symbol)
+	if !ok {
+		return Symbol{}, "", false
+	}
+	pair, ok := c.pairs[sym.Symbol][currency]
+	return sym, pair, ok
+}
+
+func bnT2Q(s Symbol, t bnTicker) Quote {
+	ret := Quote{
+		Symbol: s,
+	}
+
+	ret.Price = t.LastPrice
+	ret.Volume24H = t.QuoteVolume
+	ret.Volume24Hbase = t.Volume
+	ret.Volume24Hquote = t.QuoteVolume
+	ret.PercentChange24H = t.PriceChangePercent
+	ret.LastUpdated = time.UnixMilli(t.CloseTime)
+
+	return ret
+}
+
+func (c *binance) GetQuotes(ctx context.Context, currency string, symbol ...Symbol) ([]Quote, error) {
+	const N = 100
+
+	bySymbol := make(map[string]Symbol, len(symbol))
+	pairs := make([]string, 0, len(symbol))
+	for _, s := range symbol {
+		if sym, pair, ok := c.pair(currency, s); ok {
+			bySymbol[pair] = sym
+			pairs = append(pairs, pair)
+		}
+	}
+	if len(pairs) == 0 && len(symbol) > 0 {
+		return nil, unknownSymbols(symbol)
+	}
+
+	quotes := make([]Quote, 0, len(pairs))
+	var slice []string
+	for len(pairs) > 0 {
+		n := N
+		if len(pairs) < n {
+			n = len(pairs)
+		}
+		slice, pairs = pairs[:n], pairs[n:]
+
+		list, err := json.Marshal(slice)
+		if err != nil {
+			return nil, err
+		}
+
+		var tickers []bnTicker
+		err = getJSON(ctx, fmt.Sprintf("%s/api/v3/ticker/24hr?symbols=%s", c.url, url.QueryEscape(string(list))), &tickers, bnError)
+		if err != nil {
+			logger.Log.Error().Err(err).Str("pairs", strings.Join(slice, ",")).Msg("Could not get latest quotes")
+			return nil, err
+		}
+
+		for _, t := range tickers {
+			if sym, ok := bySymbol[t.Symbol]; ok {
+				quotes = append(quotes, bnT2Q(sym, t))
+			}
+		}
+	}
+
+	return quotes, nil
+}
+
+func bnParseKline(kline []interface{}) (timeOpen, timeClose time.Time, q OhlcvQuote, err error) {
+	if len(kline) < 8 {
+		err = fmt.Errorf("short kline: %v", kline)
+		return
+	}
+
+	num := func(i int) float64 {
+		if err != nil {
+			return 0
+		}
+		var f float64
+		switch v := kline[i].(type) {
+		case string:
+			f, err = strconv.ParseFloat(v, 64)
+		case float64:
+			f = v
+		default:
+			err = fmt.Errorf("unexpected kline field %d: %v", i, kline[i])
+		}
+		return f
+	}
+
+	timeOpen = time.UnixMilli(int64(num(0)))
+	q.Open = num(1)
+	q.High = num(2)
+	q.Low = num(3)
+	q.Close = num(4)
+	timeClose = time.UnixMilli(int64(num(6)))
+	q.Volume = num(7)
+	q.Timestamp = timeClose
+	q.LastUpdated = timeClose
+
+	return
+}
+
+var bnIntervals = map[time.Duration]string{
+	time.Minute:        "1m",
+	time.Minute * 3:    "3m",
+	time.Minute * 5:    "5m",
+	time.Minute * 15:   "15m",
+	time.Minute * 30:   "30m",
+	time.Hour:          "1h",
+	time.Hour * 2:      "2h",
+	time.Hour * 4:      "4h",
+	time.Hour * 6:      "6h",
+	time.Hour * 8:      "8h",
+	time.Hour * 12:     "12h",
+	time.Hour * 24:     "1d",
+	time.Hour * 24 * 3: "3d",
+	time.Hour * 24 * 7: "1w",
+}
+
+func (c *binance) GetOHLCV(ctx context.Context, currency string, interval time.Duration, start, end time.Time, symbol ...Symbol) ([]Ohlcv, error) {
+	const N = 1000
+
+	name, ok := bnIntervals[interval]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrInterval, interval)
+	}
+
+	var ret []Ohlcv
+	for _, s := range symbol {
+		sym, pair, ok := c.pair(currency, s)
+		if !ok {
+			continue
+		}
+
+		for from := start; from.Before(end); {
+			var klines [][]interface{}
+			err := getJSON(ctx, fmt.Sprintf("%s/api/v3/klines?symbol=%s&interval=%s&startTime=%d&endTime=%d&limit=%d",
+				c.url, url.QueryEscape(pair), name, from.UnixMilli(), end.UnixMilli(), N), &klines, bnError)
+			if err != nil {
+				logger.Log.Error().Err(err).Str("pair", pair).Msg("Could not get klines")
+				return nil, err
+			}
+
+			for _, kline := range klines {
+				timeOpen, timeClose, q, err := bnParseKline(kline)
+				if err != nil {
+					return nil, err
+				}
+				ret = append(ret, Ohlcv{
+					Symbol:      sym,
+					TimeOpen:    timeOpen,
+					TimeClose:   timeClose,
+					LastUpdated: q.LastUpdated,
+					Quote: map[string]OhlcvQuote{
+						currency: q,
+					},
+				})
+				from = timeClose.Add(time.Millisecond)
+			}
+
+			if len(klines) < N {
+				break
+			}
+		}
+	}
+
+	return ret, nil
+}
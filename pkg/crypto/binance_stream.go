@@ -0,0 +1,173 @@
+package crypto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gobwas/ws/wsutil"
+	"github.com/itohio/CoinWatcher/pkg/logger"
+)
+
+// bnReconnect paces stream reconnects, Attempts is not used since reconnecting goes on until cancelled.
+var bnReconnect = RetryPolicy{
+	Min: time.Second,
+	Max: time.Minute,
+}
+
+type bnTickerEvent struct {
+	Event              string  `json:"e"`
+	EventTime          int64   `json:"E"`
+	Symbol             string  `json:"s"`
+	PriceChange        string  `json:"p"`
+	PriceChangePercent float64 `json:"P,string"`
+	LastPrice          float64 `json:"c,string"`
+	CloseTime          int64   `json:"C"`
+	Volume             float64 `json:"v,string"`
+	QuoteVolume        float64 `json:"q,string"`
+	LastQuantity       string  `json:"Q"`
+}
+
+type bnSubscribe struct {
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+	ID     int      `json:"id"`
+}
+
+type bnConn struct {
+	io.Reader
+	net.Conn
+}
+
+func (c bnConn) Read(p []byte) (int, error) {
+	return c.Reader.Read(p)
+}
+
+// dialStream connects to the ticker stream and subscribes to streams.
+func (c *binance) dialStream(ctx context.Context, streams []string) (*bnConn, error) {
+	conn, br, _, err := wsDialer().Dial(ctx, c.streamURL)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &bnConn{Reader: conn, Conn: conn}
+	if br != nil {
+		ret.Reader = io.MultiReader(br, conn)
+	}
+
+	msg, err := json.Marshal(bnSubscribe{
+		Method: "SUBSCRIBE",
+		Params: streams,
+		ID:     1,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := wsutil.WriteClientText(conn, msg); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// readStream forwards ticker events from conn to quotes until the connection fails or ctx is done.
+func (c *binance) readStream(ctx context.Context, conn *bnConn, pairs map[string]Symbol, quotes chan<- Quote) error {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		data, err := wsutil.ReadServerText(conn)
+		if err != nil {
+			return err
+		}
+
+		var event bnTickerEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			logger.Log.Warn().Err(err).Str("data", string(data)).Msg("Bad stream message")
+			continue
+		}
+		sym, ok := pairs[event.Symbol]
+		if event.Event != "24hrTicker" || !ok {
+			continue
+		}
+
+		select {
+		case quotes <- bnT2Q(sym, bnTicker{
+			Symbol:             event.Symbol,
+			PriceChangePercent: event.PriceChangePercent,
+			LastPrice:          event.LastPrice,
+			Volume:             event.Volume,
+			QuoteVolume:        event.QuoteVolume,
+			CloseTime:          event.CloseTime,
+		}):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Subscribe streams 24h ticker updates. The connection is reestablished and resubscribed
+// with exponential backoff until ctx is done.
+func (c *binance) Subscribe(ctx context.Context, currency string, symbol ...Symbol) (<-chan Quote, error) {
+	pairs := make(map[string]Symbol, len(symbol))
+	streams := make([]string, 0, len(symbol))
+	for _, s := range symbol {
+		if sym, pair, ok := c.pair(currency, s); ok {
+			pairs[pair] = sym
+			streams = append(streams, strings.ToLower(pair)+"@ticker")
+		}
+	}
+	if len(streams) == 0 {
+		if len(symbol) > 0 {
+			return nil, unknownSymbols(symbol)
+		}
+		return nil, errors.New("nothing to subscribe to")
+	}
+
+	conn, err := c.dialStream(ctx, streams)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := make(chan Quote)
+	go func() {
+		defer close(quotes)
+		for {
+			err := c.readStream(ctx, conn, pairs, quotes)
+			conn.Close()
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Log.Warn().Err(err).Msg("Stream disconnected")
+
+			for attempt := 0; ; attempt++ {
+				backoff := bnReconnect.Delay(attempt)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(backoff):
+				}
+				if conn, err = c.dialStream(ctx, streams); err == nil {
+					break
+				}
+				logger.Log.Warn().Err(err).Dur("backoff", backoff).Msg("Stream reconnect failed")
+			}
+			logger.Log.Info().Int("streams", len(streams)).Msg("Stream reconnected")
+		}
+	}()
+
+	return quotes, nil
+}
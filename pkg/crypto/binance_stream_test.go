@@ -0,0 +1,158 @@
+package crypto
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+)
+
+// bnStreamServer serves exchangeInfo and a ticker stream. Each connection gets the frames of its turn,
+// the connection is dropped after all but the last turn.
+func bnStreamServer(t *testing.T, turns ...[]string) (*httptest.Server, *[]bnSubscribe) {
+	t.Helper()
+	info, _ := bnServer(t)
+	var (
+		lock       sync.Mutex
+		subscribed []bnSubscribe
+	)
+	done := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ws" {
+			http.Redirect(w, r, info.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return
+		}
+		conn, _, _, err := ws.UpgradeHTTP(r, w)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+
+		data, err := wsutil.ReadClientText(conn)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		var sub bnSubscribe
+		if err := json.Unmarshal(data, &sub); err != nil {
+			t.Error(err)
+			return
+		}
+		lock.Lock()
+		turn := len(subscribed)
+		subscribed = append(subscribed, sub)
+		lock.Unlock()
+		if turn >= len(turns) {
+			return
+		}
+
+		for _, frame := range turns[turn] {
+			if err := wsutil.WriteServerText(conn, []byte(frame)); err != nil {
+				return
+			}
+		}
+		if turn == len(turns)-1 {
+			<-done
+		}
+	}))
+	t.Cleanup(func() {
+		close(done)
+		srv.Close()
+	})
+	return srv, &subscribed
+}
+
+func bnTickerFrame(pair, price string, closeTime int64) string {
+	b, _ := json.Marshal(map[string]interface{}{
+		"e": "24hrTicker", "E": closeTime + 1, "s": pair, "p": "1.0", "P": "0.25",
+		"c": price, "C": closeTime, "v": "12.5", "q": "500000.5", "Q": "0.1",
+	})
+	return string(b)
+}
+
+func TestBinanceStreamReconnect(t *testing.T) {
+	defer func(p RetryPolicy) { bnReconnect = p }(bnReconnect)
+	bnReconnect = RetryPolicy{Min: time.Millisecond * 10, Max: time.Millisecond * 20}
+
+	srv, subscribed := bnStreamServer(t,
+		[]string{
+			`{"result":null,"id":1}`,
+			bnTickerFrame("BTCUSDT", "43000.5", 1704189599999),
+			bnTickerFrame("ETHBTC", "0.055", 1704189599999),
+			`not json`,
+		},
+		[]string{
+			bnTickerFrame("ETHUSDT", "2390.25", 1704189659999),
+		},
+	)
+	feed, err := NewBinance(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	quotes, err := feed.Subscribe(ctx, "USDT", Symbol{Symbol: "BTC"}, Symbol{Symbol: "ETH"}, Symbol{Symbol: "NOPE"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []Quote
+	for len(got) < 2 {
+		select {
+		case q, ok := <-quotes:
+			if !ok {
+				t.Fatal("stream closed")
+			}
+			got = append(got, q)
+		case <-ctx.Done():
+			t.Fatalf("got %d quotes before timing out", len(got))
+		}
+	}
+
+	btc, eth := got[0], got[1]
+	if btc.Symbol.Symbol != "BTC" || btc.Price != 43000.5 || btc.PercentChange24H != 0.25 ||
+		btc.Volume24H != 500000.5 || btc.Volume24Hbase != 12.5 || !btc.LastUpdated.Equal(time.UnixMilli(1704189599999)) {
+		t.Fatalf("unexpected quote %+v", btc)
+	}
+	if eth.Symbol.Symbol != "ETH" || eth.Price != 2390.25 {
+		t.Fatalf("expected a quote after reconnecting, got %+v", eth)
+	}
+
+	if len(*subscribed) != 2 {
+		t.Fatalf("expected 2 connections, got %d", len(*subscribed))
+	}
+	for _, sub := range *subscribed {
+		if sub.Method != "SUBSCRIBE" || strings.Join(sub.Params, ",") != "btcusdt@ticker,ethusdt@ticker" {
+			t.Fatalf("unexpected subscription %+v", sub)
+		}
+	}
+
+	cancel()
+	for range quotes {
+	}
+}
+
+func TestBinanceStreamUnknown(t *testing.T) {
+	srv, subscribed := bnStreamServer(t)
+	feed, err := NewBinance(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := feed.Subscribe(context.Background(), "EUR", Symbol{Symbol: "ETH"}); err == nil {
+		t.Fatal("expected an error for pairs that are not listed")
+	}
+	if len(*subscribed) != 0 {
+		t.Fatal("connected without anything to subscribe to")
+	}
+}
@@ -0,0 +1,251 @@
+package crypto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// bnServer serves recorded Binance responses and hourly klines generated for the requested range.
+// Klines have a base volume of 10 and a quote volume of 1000 times their index.
+func bnServer(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	var (
+		lock     sync.Mutex
+		requests []string
+	)
+	fixture := func(name string) []byte {
+		b, err := os.ReadFile(filepath.Join("testdata", "binance", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests = append(requests, r.URL.RequestURI())
+		lock.Unlock()
+
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/api/v3/exchangeInfo":
+			w.Write(fixture("exchangeInfo.json"))
+		case "/api/v3/ticker/24hr":
+			var pairs []string
+			if err := json.Unmarshal([]byte(q.Get("symbols")), &pairs); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			var tickers, filtered []map[string]interface{}
+			json.Unmarshal(fixture("ticker.json"), &tickers)
+			for _, t := range tickers {
+				for _, p := range pairs {
+					if t["symbol"] == p {
+						filtered = append(filtered, t)
+					}
+				}
+			}
+			json.NewEncoder(w).Encode(filtered)
+		case "/api/v3/klines":
+			start, _ := strconv.ParseInt(q.Get("startTime"), 10, 64)
+			end, _ := strconv.ParseInt(q.Get("endTime"), 10, 64)
+			limit, _ := strconv.Atoi(q.Get("limit"))
+			hour := time.Hour.Milliseconds()
+			var klines [][]interface{}
+			for open := (start + hour - 1) / hour * hour; open+hour-1 <= end && len(klines) < limit; open += hour {
+				i := float64(open / hour % 1000)
+				klines = append(klines, []interface{}{
+					open, strconv.FormatFloat(100+i, 'f', -1, 64), strconv.FormatFloat(110+i, 'f', -1, 64),
+					strconv.FormatFloat(90+i, 'f', -1, 64), strconv.FormatFloat(105+i, 'f', -1, 64),
+					"10", open + hour - 1, strconv.FormatFloat(1000*i, 'f', -1, 64), 42, "5", "500", "0",
+				})
+			}
+			json.NewEncoder(w).Encode(klines)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestBinancePairs(t *testing.T) {
+	srv, _ := bnServer(t)
+	feed, err := NewBinance(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(feed.GetSymbols()); got != 2 {
+		t.Fatalf("expected BTC and ETH, got %d symbols", got)
+	}
+	if _, ok := feed.FindSymbol("LUNA"); ok {
+		t.Fatal("pairs that are not trading are listed")
+	}
+	if got := strings.Join(feed.GetCurrencies(), ","); got != "BTC,EUR,USDT" {
+		t.Fatalf("unexpected currencies %s", got)
+	}
+	for _, tc := range []struct {
+		symbol, currency, pair string
+		ok                     bool
+	}{
+		{"BTC", "USDT", "BTCUSDT", true},
+		{"BTC", "EUR", "BTCEUR", true},
+		{"ETH", "BTC", "ETHBTC", true},
+		{"ETH", "EUR", "", false},
+		{"DOGE", "USDT", "", false},
+	} {
+		_, pair, ok := feed.pair(tc.currency, Symbol{Symbol: tc.symbol})
+		if pair != tc.pair || ok != tc.ok {
+			t.Errorf("%s/%s: got %q %v, want %q %v", tc.symbol, tc.currency, pair, ok, tc.pair, tc.ok)
+		}
+	}
+	if streamURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"; feed.streamURL != streamURL {
+		t.Fatalf("stream url %s, want %s", feed.streamURL, streamURL)
+	}
+}
+
+func TestBinanceQuotes(t *testing.T) {
+	srv, requests := bnServer(t)
+	feed, err := NewBinance(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quotes, err := feed.GetQuotes(context.Background(), "USDT", Symbol{Symbol: "BTC"}, Symbol{Symbol: "ETH"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := (*requests)[len(*requests)-1]
+	if !strings.Contains(last, "symbols=%5B%22BTCUSDT%22%2C%22ETHUSDT%22%5D") {
+		t.Fatalf("unexpected ticker request %s", last)
+	}
+	if len(quotes) != 2 {
+		t.Fatalf("expected 2 quotes, got %d", len(quotes))
+	}
+
+	btc := quotes[0]
+	if btc.Symbol.Symbol != "BTC" || btc.Symbol.Provider != bnName {
+		t.Fatalf("unexpected symbol %+v", btc.Symbol)
+	}
+	if btc.Price != 43199.9 || btc.PercentChange24H != -1.397 {
+		t.Fatalf("unexpected price %v or change %v", btc.Price, btc.PercentChange24H)
+	}
+	if btc.Volume24H != 1360212345.1234 || btc.Volume24Hquote != 1360212345.1234 || btc.Volume24Hbase != 31234.567 {
+		t.Fatalf("unexpected volumes %v %v %v", btc.Volume24H, btc.Volume24Hbase, btc.Volume24Hquote)
+	}
+	if !btc.LastUpdated.Equal(time.UnixMilli(1704189599999)) {
+		t.Fatalf("unexpected update time %v", btc.LastUpdated)
+	}
+
+	if _, err := feed.GetQuotes(context.Background(), "EUR", Symbol{Symbol: "ETH"}); err == nil {
+		t.Fatal("expected an error for a pair that is not listed")
+	}
+}
+
+func TestBinanceOHLCV(t *testing.T) {
+	srv, requests := bnServer(t)
+	feed, err := NewBinance(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour * 2500)
+	before := len(*requests)
+	candles, err := feed.GetOHLCV(context.Background(), "USDT", time.Hour, start, end, Symbol{Symbol: "BTC"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(*requests) - before; n != 3 {
+		t.Fatalf("expected 3 kline pages, got %d", n)
+	}
+	if len(candles) != 2500 {
+		t.Fatalf("expected 2500 candles, got %d", len(candles))
+	}
+	for i := 1; i < len(candles); i++ {
+		if !candles[i].TimeOpen.Equal(candles[i-1].TimeOpen.Add(time.Hour)) {
+			t.Fatalf("candle %d opens at %v after %v", i, candles[i].TimeOpen, candles[i-1].TimeOpen)
+		}
+	}
+
+	c := candles[1000]
+	q := c.Quote["USDT"]
+	i := float64(c.TimeOpen.UnixMilli() / time.Hour.Milliseconds() % 1000)
+	if q.Open != 100+i || q.High != 110+i || q.Low != 90+i || q.Close != 105+i {
+		t.Fatalf("unexpected prices %+v", q)
+	}
+	// Volume is in the quote currency like quote volumes, not the base volume at index 5
+	if q.Volume != 1000*i {
+		t.Fatalf("volume %v, want quote volume %v", q.Volume, 1000*i)
+	}
+	if !c.TimeClose.Equal(c.TimeOpen.Add(time.Hour-time.Millisecond)) || !q.Timestamp.Equal(c.TimeClose) {
+		t.Fatalf("unexpected close time %v", c.TimeClose)
+	}
+
+	if _, err := feed.GetOHLCV(context.Background(), "USDT", time.Hour*5, start, end, Symbol{Symbol: "BTC"}); err == nil {
+		t.Fatal("expected an error for an unsupported interval")
+	}
+}
+
+func TestBinanceParseKline(t *testing.T) {
+	_, _, q, err := bnParseKline([]interface{}{float64(0), "1", "2", "0.5", "1.5", "7", float64(3599999), "11.5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Volume != 11.5 {
+		t.Fatalf("volume %v, want the quote asset volume 11.5", q.Volume)
+	}
+	if _, _, _, err := bnParseKline([]interface{}{float64(0), "1"}); err == nil {
+		t.Fatal("expected an error for a short kline")
+	}
+	if _, _, _, err := bnParseKline([]interface{}{float64(0), "x", "2", "0.5", "1.5", "7", float64(3599999), "11.5"}); err == nil {
+		t.Fatal("expected an error for a bad number")
+	}
+}
+
+func TestBinanceErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/banned":
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTeapot)
+		case "/symbol":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":-1121,"msg":"Invalid symbol."}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":-1100,"msg":"Illegal characters found in parameter."}`))
+		}
+	}))
+	defer srv.Close()
+
+	var v interface{}
+	err := getJSON(context.Background(), srv.URL+"/banned", &v, bnError)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("418: %v", err)
+	} else if after, _ := RetryAfter(err); after != time.Minute*2 {
+		t.Errorf("418: retry after %v", after)
+	}
+	if err := getJSON(context.Background(), srv.URL+"/symbol", &v, bnError); !errors.Is(err, ErrUnknownSymbol) {
+		t.Errorf("-1121: %v", err)
+	}
+	if err := getJSON(context.Background(), srv.URL+"/other", &v, bnError); err == nil || errors.Is(err, ErrUnknownSymbol) || Retryable(err) {
+		t.Errorf("-1100: %v", err)
+	}
+	// Other providers do not know Binance error codes
+	if err := getJSON(context.Background(), srv.URL+"/symbol", &v, nil); errors.Is(err, ErrUnknownSymbol) {
+		t.Errorf("-1121 without the Binance classifier: %v", err)
+	}
+}
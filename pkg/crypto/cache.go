@@ -1,8 +1,24 @@
 package crypto
 
-import "image"
+import (
+	"image"
+	"time"
+)
 
 type Cache interface {
 	LoadImage(url string) (image.Image, error)
 	SaveImage(url string, img image.Image)
 }
+
+// Listing is a snapshot of provider symbol listings.
+type Listing struct {
+	Updated    time.Time `json:"updated"`
+	Symbols    []Symbol  `json:"symbols"`
+	Currencies []string  `json:"currencies"`
+}
+
+// ListingCache keeps provider symbol listings between runs.
+type ListingCache interface {
+	LoadListing(provider string) (Listing, error)
+	SaveListing(provider string, listing Listing)
+}
@@ -0,0 +1,179 @@
+package crypto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hexoul/go-coinmarketcap/types"
+)
+
+const CoinMarketCapURL = "https://pro-api.coinmarketcap.com/v1"
+
+// cmcClient is a minimal CoinMarketCap Pro API client. Unlike go-coinmarketcap it is not a singleton,
+// so the feed can be recreated with another API key.
+type cmcClient struct {
+	url   string
+	key   string
+	meter CreditMeter
+}
+
+type cmcResponse struct {
+	Status types.Status    `json:"status"`
+	Data   json.RawMessage `json:"data"`
+}
+
+func newCMCClient(baseURL, key string, meter CreditMeter) (*cmcClient, error) {
+	if key == "" {
+		return nil, errors.New("CoinMarketCap API key is required")
+	}
+	if baseURL == "" {
+		baseURL = CoinMarketCapURL
+	}
+	return &cmcClient{
+		url:   strings.TrimSuffix(baseURL, "/"),
+		key:   key,
+		meter: meter,
+	}, nil
+}
+
+// cmcCredits estimates credits charged for a call returning n items following CMC credit rules.
+func cmcCredits(endpoint string, params url.Values, n int) int {
+	per := 100
+	if endpoint == "cryptocurrency/listings/latest" {
+		per = 200
+	}
+	credits := (n + per - 1) / per
+	if credits < 1 {
+		credits = 1
+	}
+	if convert := params.Get("convert"); convert != "" {
+		credits += strings.Count(convert, ",")
+	}
+	return credits
+}
+
+// cmcCount returns the number of items in a data response.
+func cmcCount(data json.RawMessage) int {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err == nil {
+		return len(items)
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err == nil {
+		if quotes, ok := obj["quotes"]; ok {
+			return cmcCount(quotes)
+		}
+		return len(obj)
+	}
+	return 1
+}
+
+func (c *cmcClient) do(ctx context.Context, endpoint string, params url.Values) (cmcResponse, error) {
+	var resp cmcResponse
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/%s?%s", c.url, endpoint, params.Encode()), nil)
+	if err != nil {
+		return resp, err
+	}
+	req.Header.Set("X-CMC_PRO_API_KEY", c.key)
+	req.Header.Set("Accept", "application/json")
+
+	response, err := httpClient().Do(req)
+	if err != nil {
+		return resp, err
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&resp); err != nil {
+		if response.StatusCode != http.StatusOK {
+			return resp, httpError(endpoint, response, nil)
+		}
+		return resp, fmt.Errorf("%s: %s: %v", endpoint, response.Status, err)
+	}
+	if resp.Status.ErrorCode != 0 {
+		msg := response.Status
+		if resp.Status.ErrorMessage != nil {
+			msg = *resp.Status.ErrorMessage
+		}
+		err := cmcError(fmt.Errorf("[%d] %s", resp.Status.ErrorCode, msg))
+		if after := parseRetryAfter(response.Header.Get("Retry-After")); after > 0 {
+			if rl, ok := err.(*RateLimitError); ok {
+				rl.RetryAfter = after
+			}
+		}
+		return resp, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return resp, httpError(endpoint, response, nil)
+	}
+	return resp, nil
+}
+
+// get calls endpoint and decodes the data part of the response into v. API errors are reported as "[code] message"
+// and classified by cmcError. Temporary failures are retried with DefaultRetry.
+func (c *cmcClient) get(ctx context.Context, endpoint string, params url.Values, v interface{}) error {
+	var resp cmcResponse
+	err := DefaultRetry.Do(ctx, func() error {
+		var err error
+		resp, err = c.do(ctx, endpoint, params)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.meter != nil {
+		credits := resp.Status.CreditCount
+		if credits == 0 {
+			credits = cmcCredits(endpoint, params, cmcCount(resp.Data))
+		}
+		c.meter.AddCredits(cmcName, credits)
+	}
+
+	return json.Unmarshal(resp.Data, v)
+}
+
+func (c *cmcClient) listingsLatest(ctx context.Context, limit int) ([]*types.CryptoMarket, error) {
+	var ret []*types.CryptoMarket
+	err := c.get(ctx, "cryptocurrency/listings/latest", url.Values{
+		"limit": {fmt.Sprint(limit)},
+	}, &ret)
+	return ret, err
+}
+
+// cmcInfo is an item of cryptocurrency/info. go-coinmarketcap types lack the description.
+type cmcInfo struct {
+	types.CryptoInfo
+	Description string              `json:"description"`
+	Urls        map[string][]string `json:"urls,omitempty"`
+}
+
+func (c *cmcClient) info(ctx context.Context, ids ...string) (map[string]*cmcInfo, error) {
+	var ret map[string]*cmcInfo
+	err := c.get(ctx, "cryptocurrency/info", url.Values{
+		"id": {strings.Join(ids, ",")},
+	}, &ret)
+	return ret, err
+}
+
+func (c *cmcClient) quotesLatest(ctx context.Context, convert string, ids ...string) (map[string]*types.CryptoMarket, error) {
+	params := url.Values{
+		"id": {strings.Join(ids, ",")},
+	}
+	if convert != "" {
+		params.Set("convert", convert)
+	}
+	var ret map[string]*types.CryptoMarket
+	err := c.get(ctx, "cryptocurrency/quotes/latest", params, &ret)
+	return ret, err
+}
+
+func (c *cmcClient) ohlcvHistorical(ctx context.Context, params url.Values) (*types.OhlcvList, error) {
+	var ret types.OhlcvList
+	err := c.get(ctx, "cryptocurrency/ohlcv/historical", params, &ret)
+	return &ret, err
+}
@@ -0,0 +1,45 @@
+package crypto
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+)
+
+func TestCMCCredits(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		endpoint string
+		params   url.Values
+		n        int
+		want     int
+	}{
+		{"empty", "cryptocurrency/info", nil, 0, 1},
+		{"one page", "cryptocurrency/info", nil, 100, 1},
+		{"two pages", "cryptocurrency/info", nil, 101, 2},
+		{"listings", "cryptocurrency/listings/latest", nil, 200, 1},
+		{"listings pages", "cryptocurrency/listings/latest", nil, 1500, 8},
+		{"one convert", "cryptocurrency/quotes/latest", url.Values{"convert": {"USD"}}, 1, 1},
+		{"extra converts", "cryptocurrency/quotes/latest", url.Values{"convert": {"USD,EUR,BTC"}}, 150, 4},
+	} {
+		if got := cmcCredits(tc.endpoint, tc.params, tc.n); got != tc.want {
+			t.Errorf("%s: got %d credits, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCMCCount(t *testing.T) {
+	for _, tc := range []struct {
+		data string
+		want int
+	}{
+		{`[{"id":1},{"id":2}]`, 2},
+		{`{"1":{},"2":{},"3":{}}`, 3},
+		{`{"id":1,"quotes":[{},{}]}`, 2},
+		{`"text"`, 1},
+	} {
+		if got := cmcCount(json.RawMessage(tc.data)); got != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.data, got, tc.want)
+		}
+	}
+}
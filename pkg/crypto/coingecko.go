@@ -0,0 +1,280 @@
+package crypto
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/itohio/CoinWatcher/pkg/logger"
+)
+
+const (
+	CoinGeckoURL = "https://api.coingecko.com/api/v3"
+	cgName       = "CoinGecko"
+)
+
+type coingecko struct {
+	url        string
+	symbols    []Symbol
+	ids        map[int]string
+	byId       map[string]Symbol
+	currencies []string
+	iconCache  Cache
+}
+
+var _ Crypto = &coingecko{}
+
+type cgMarket struct {
+	ID               string  `json:"id"`
+	Symbol           string  `json:"symbol"`
+	Name             string  `json:"name"`
+	Image            string  `json:"image"`
+	CurrentPrice     float64 `json:"current_price"`
+	MarketCap        float64 `json:"market_cap"`
+	TotalVolume      float64 `json:"total_volume"`
+	PercentChange1H  float64 `json:"price_change_percentage_1h_in_currency"`
+	PercentChange24H float64 `json:"price_change_percentage_24h_in_currency"`
+	PercentChange7D  float64 `json:"price_change_percentage_7d_in_currency"`
+	PercentChange30D float64 `json:"price_change_percentage_30d_in_currency"`
+	LastUpdated      string  `json:"last_updated"`
+}
+
+// NewCoinGecko creates a CoinGecko backed feed. Empty baseURL means the public CoinGecko API.
+func NewCoinGecko(ctx context.Context, baseURL string, iconCache Cache) (*coingecko, error) {
+	const (
+		N     = 1500
+		pageN = 250
+	)
+
+	if baseURL == "" {
+		baseURL = CoinGeckoURL
+	}
+	ret := &coingecko{
+		url:       strings.TrimSuffix(baseURL, "/"),
+		ids:       make(map[int]string),
+		byId:      make(map[string]Symbol),
+		iconCache: iconCache,
+	}
+
+	for page := 1; page*pageN <= N; page++ {
+		var markets []cgMarket
+		err := getJSON(ctx, fmt.Sprintf("%s/coins/markets?vs_currency=usd&order=market_cap_desc&per_page=%d&page=%d", ret.url, pageN, page), &markets, nil)
+		if err != nil {
+			return nil, fmt.Errorf("Could not get symbol list: %v", err)
+		}
+		for _, m := range markets {
+			ret.add(m)
+		}
+		if len(markets) < pageN {
+			break
+		}
+	}
+
+	var currencies []string
+	if err := getJSON(ctx, ret.url+"/simple/supported_vs_currencies", &currencies, nil); err != nil {
+		return nil, fmt.Errorf("Could not get currencies: %v", err)
+	}
+	for _, c := range currencies {
+		ret.currencies = append(ret.currencies, strings.ToUpper(c))
+	}
+	sort.Sort(sort.StringSlice(ret.currencies))
+
+	logger.Log.Debug().Int("symbols", len(ret.symbols)).Int("currencies", len(ret.currencies)).Msg("Fetched CoinGecko symbols")
+
+	return ret, nil
+}
+
+func (c *coingecko) Name() string {
+	return cgName
+}
+
+func (c *coingecko) GetSymbols() []Symbol {
+	return c.symbols
+}
+
+func (c *coingecko) GetCurrencies() []string {
+	return c.currencies
+}
+
+func (c *coingecko) FindSymbol(symbol string) (Symbol, bool) {
+	for _, s := range c.symbols {
+		if s.Symbol == symbol {
+			return s, true
+		}
+	}
+	return Symbol{}, false
+}
+
+// add lists the coin of a market. Coins whose ids hash to a taken Symbol.Id get the next free one,
+// so the coin listed first by market cap keeps the hashed id.
+func (c *coingecko) add(m cgMarket) {
+	if _, ok := c.byId[m.ID]; ok {
+		return
+	}
+	id := idFromString(m.ID)
+	for _, ok := c.ids[id]; ok; _, ok = c.ids[id] {
+		id = (id + 1) & 0x7fffffff
+	}
+	s := Symbol{
+		Id:        id,
+		Provider:  cgName,
+		Name:      m.Name,
+		Symbol:    strings.ToUpper(m.Symbol),
+		IconURL:   m.Image,
+		iconCache: c.iconCache,
+	}
+	c.ids[id] = m.ID
+	c.byId[m.ID] = s
+	c.symbols = append(c.symbols, s)
+}
+
+func (c *coingecko) findId(id string) (Symbol, bool) {
+	s, ok := c.byId[id]
+	return s, ok
+}
+
+func cgM2Q(s Symbol, m cgMarket) Quote {
+	ret := Quote{
+		Symbol: s,
+	}
+
+	ret.Price = m.CurrentPrice
+	ret.MarketCap = m.MarketCap
+	ret.Volume24H = m.TotalVolume
+	ret.PercentChange1H = m.PercentChange1H
+	ret.PercentChange24H = m.PercentChange24H
+	ret.PercentChange7D = m.PercentChange7D
+	ret.PercentChange30D = m.PercentChange30D
+
+	if t, err := time.Parse(time.RFC3339, m.LastUpdated); err == nil {
+		ret.LastUpdated = t
+	}
+
+	return ret
+}
+
+func (c *coingecko) GetQuotes(ctx context.Context, currency string, symbol ...Symbol) ([]Quote, error) {
+	const N = 250
+
+	ids := make([]string, 0, len(symbol))
+	for _, s := range symbol {
+		if sym, ok := lookup(c.symbols, s); ok {
+			ids = append(ids, c.ids[sym.Id])
+		}
+	}
+	if len(ids) == 0 && len(symbol) > 0 {
+		return nil, unknownSymbols(symbol)
+	}
+
+	quotes := make([]Quote, 0, len(ids))
+	var slice []string
+	for len(ids) > 0 {
+		n := N
+		if len(ids) < n {
+			n = len(ids)
+		}
+		slice, ids = ids[:n], ids[n:]
+
+		var markets []cgMarket
+		err := getJSON(ctx, fmt.Sprintf("%s/coins/markets?vs_currency=%s&ids=%s&per_page=%d&price_change_percentage=1h,24h,7d,30d",
+			c.url, url.QueryEscape(strings.ToLower(currency)), url.QueryEscape(strings.Join(slice, ",")), N), &markets, nil)
+		if err != nil {
+			logger.Log.Error().Err(err).Str("ids", strings.Join(slice, ",")).Msg("Could not get latest quotes")
+			return nil, err
+		}
+
+		for _, m := range markets {
+			if sym, ok := c.findId(m.ID); ok {
+				quotes = append(quotes, cgM2Q(sym, m))
+			}
+		}
+	}
+
+	return quotes, nil
+}
+
+// cgDays picks the smallest range supported by the ohlc endpoint that reaches back to start.
+func cgDays(start time.Time) string {
+	days := time.Since(start).Hours() / 24
+	for _, d := range []float64{1, 7, 14, 30, 90, 180, 365} {
+		if days <= d {
+			return fmt.Sprint(d)
+		}
+	}
+	return "max"
+}
+
+// cgAggregate merges CoinGecko candles, which are keyed by their close time, into interval wide candles.
+func cgAggregate(s Symbol, currency string, interval time.Duration, start, end time.Time, candles [][]float64) []Ohlcv {
+	var ret []Ohlcv
+	for _, candle := range candles {
+		if len(candle) < 5 {
+			continue
+		}
+		ts := time.UnixMilli(int64(candle[0]))
+		if ts.Before(start) || ts.After(end) {
+			continue
+		}
+
+		timeOpen := ts.Add(-time.Nanosecond).Truncate(interval)
+		if n := len(ret); n == 0 || !ret[n-1].TimeOpen.Equal(timeOpen) {
+			ret = append(ret, Ohlcv{
+				Symbol:    s,
+				TimeOpen:  timeOpen,
+				TimeClose: timeOpen.Add(interval),
+				Quote: map[string]OhlcvQuote{
+					currency: {
+						Open: candle[1],
+						High: candle[2],
+						Low:  candle[3],
+					},
+				},
+			})
+		}
+
+		o := &ret[len(ret)-1]
+		q := o.Quote[currency]
+		if candle[2] > q.High {
+			q.High = candle[2]
+		}
+		if candle[3] < q.Low {
+			q.Low = candle[3]
+		}
+		q.Close = candle[4]
+		q.Timestamp = ts
+		q.LastUpdated = ts
+		o.Quote[currency] = q
+		o.LastUpdated = ts
+	}
+
+	return ret
+}
+
+func (c *coingecko) GetOHLCV(ctx context.Context, currency string, interval time.Duration, start, end time.Time, symbol ...Symbol) ([]Ohlcv, error) {
+	if interval < time.Minute*30 {
+		return nil, fmt.Errorf("%w: %v", ErrInterval, interval)
+	}
+
+	var ret []Ohlcv
+	for _, s := range symbol {
+		sym, ok := lookup(c.symbols, s)
+		if !ok {
+			continue
+		}
+
+		var candles [][]float64
+		err := getJSON(ctx, fmt.Sprintf("%s/coins/%s/ohlc?vs_currency=%s&days=%s",
+			c.url, url.PathEscape(c.ids[sym.Id]), url.QueryEscape(strings.ToLower(currency)), cgDays(start)), &candles, nil)
+		if err != nil {
+			logger.Log.Error().Err(err).Str("symbol", s.Symbol).Msg("Could not get ohlc")
+			return nil, err
+		}
+
+		ret = append(ret, cgAggregate(sym, currency, interval, start, end, candles)...)
+	}
+
+	return ret, nil
+}
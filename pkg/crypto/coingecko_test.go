@@ -0,0 +1,184 @@
+package crypto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// cgServer serves recorded CoinGecko responses. The first markets page is filled up to the page size,
+// so the feed has to ask for the second one.
+func cgServer(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	var (
+		lock     sync.Mutex
+		requests []string
+	)
+	fixture := func(name string) []byte {
+		b, err := os.ReadFile(filepath.Join("testdata", "coingecko", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests = append(requests, r.URL.RequestURI())
+		lock.Unlock()
+
+		q := r.URL.Query()
+		switch {
+		case r.URL.Path == "/coins/markets" && q.Get("ids") != "":
+			w.Write(fixture("quotes.json"))
+		case r.URL.Path == "/coins/markets" && q.Get("page") == "1":
+			var items []string
+			for i := 0; i < 250; i++ {
+				items = append(items, fmt.Sprintf(`{"id":"token-%d","symbol":"t%d","name":"Token %d"}`, i, i, i))
+			}
+			fmt.Fprintf(w, "[%s]", strings.Join(items, ","))
+		case r.URL.Path == "/coins/markets" && q.Get("page") == "2":
+			w.Write(fixture("markets_page2.json"))
+		case r.URL.Path == "/coins/markets":
+			w.Write([]byte("[]"))
+		case r.URL.Path == "/simple/supported_vs_currencies":
+			w.Write(fixture("currencies.json"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestCoinGeckoLoad(t *testing.T) {
+	srv, requests := cgServer(t)
+
+	feed, err := NewCoinGecko(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pages []string
+	for _, r := range *requests {
+		if strings.HasPrefix(r, "/coins/markets?") {
+			pages = append(pages, r)
+		}
+	}
+	if len(pages) != 2 || !strings.Contains(pages[0], "page=1") || !strings.Contains(pages[1], "page=2") {
+		t.Fatalf("expected two market pages, got %v", pages)
+	}
+	if n := len(feed.GetSymbols()); n != 253 {
+		t.Fatalf("expected 253 symbols, got %d", n)
+	}
+	if got, want := feed.GetCurrencies(), []string{"BTC", "ETH", "EUR", "USD"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("currencies %v, want %v", got, want)
+	}
+
+	btc, ok := feed.FindSymbol("BTC")
+	if !ok {
+		t.Fatal("BTC not found")
+	}
+	if btc.Provider != cgName || btc.Name != "Bitcoin" || btc.Id != idFromString("bitcoin") {
+		t.Fatalf("unexpected BTC symbol %+v", btc)
+	}
+}
+
+func TestCoinGeckoIds(t *testing.T) {
+	if idFromString("bitcoin") != idFromString("bitcoin") {
+		t.Fatal("ids are not stable")
+	}
+	if idFromString("ethereum") == idFromString("ethereum-wormhole") {
+		t.Fatal("coins sharing a ticker have the same id")
+	}
+	if id := idFromString("bitcoin"); id < 0 {
+		t.Fatalf("negative id %d", id)
+	}
+}
+
+func TestCoinGeckoIdCollision(t *testing.T) {
+	c := &coingecko{ids: make(map[int]string), byId: make(map[string]Symbol)}
+	if idFromString("coin-288904") != idFromString("coin-658220") {
+		t.Fatal("test ids do not collide")
+	}
+	first := cgMarket{ID: "coin-288904", Symbol: "one", Name: "One"}
+	second := cgMarket{ID: "coin-658220", Symbol: "two", Name: "Two"}
+	c.add(first)
+	c.add(second)
+	c.add(first)
+	if len(c.symbols) != 2 {
+		t.Fatalf("listed %d symbols, want 2", len(c.symbols))
+	}
+
+	for _, tc := range []struct {
+		id   string
+		want int
+	}{
+		{first.ID, idFromString(first.ID)},
+		{second.ID, idFromString(first.ID) + 1},
+	} {
+		s, ok := c.findId(tc.id)
+		if !ok || s.Id != tc.want {
+			t.Errorf("%s: got %+v, %v, want id %d", tc.id, s, ok, tc.want)
+		}
+		if c.ids[tc.want] != tc.id {
+			t.Errorf("id %d maps to %q, want %q", tc.want, c.ids[tc.want], tc.id)
+		}
+	}
+}
+
+func TestCoinGeckoQuotes(t *testing.T) {
+	srv, requests := cgServer(t)
+	feed, err := NewCoinGecko(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wormhole := Symbol{Provider: cgName, Id: idFromString("ethereum-wormhole"), Symbol: "ETH"}
+	quotes, err := feed.GetQuotes(context.Background(), "EUR", Symbol{Symbol: "BTC"}, wormhole)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last := (*requests)[len(*requests)-1]
+	if !strings.Contains(last, "vs_currency=eur") || !strings.Contains(last, "ids=bitcoin%2Cethereum-wormhole") {
+		t.Fatalf("unexpected quotes request %s", last)
+	}
+	if len(quotes) != 2 {
+		t.Fatalf("expected 2 quotes, got %d", len(quotes))
+	}
+
+	btc := quotes[0]
+	want := Quote{
+		Price:            39500.25,
+		MarketCap:        773000000000,
+		Volume24H:        19200000000,
+		PercentChange1H:  0.12,
+		PercentChange24H: -1.5,
+		PercentChange7D:  3.25,
+		PercentChange30D: 10.5,
+	}
+	if btc.Symbol.Symbol != "BTC" || btc.Price != want.Price || btc.MarketCap != want.MarketCap || btc.Volume24H != want.Volume24H ||
+		btc.PercentChange1H != want.PercentChange1H || btc.PercentChange24H != want.PercentChange24H ||
+		btc.PercentChange7D != want.PercentChange7D || btc.PercentChange30D != want.PercentChange30D {
+		t.Fatalf("unexpected BTC quote %+v", btc)
+	}
+	if !btc.LastUpdated.Equal(time.Date(2024, 1, 2, 10, 1, 2, 0, time.UTC)) {
+		t.Fatalf("unexpected update time %v", btc.LastUpdated)
+	}
+	if eth := quotes[1]; eth.Symbol.Name != "Ethereum (Wormhole)" || eth.Symbol.Id != wormhole.Id || eth.Price != 2175.5 {
+		t.Fatalf("quote is not mapped to the requested coin: %+v", eth)
+	}
+
+	if _, err := feed.GetQuotes(context.Background(), "EUR", Symbol{Symbol: "NOPE"}); !errors.Is(err, ErrUnknownSymbol) {
+		t.Fatalf("expected unknown symbol error, got %v", err)
+	}
+}
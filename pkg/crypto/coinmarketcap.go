@@ -1,43 +1,137 @@
 package crypto
 
 import (
+	"context"
 	"fmt"
+	"net/url"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
-	cmc "github.com/hexoul/go-coinmarketcap"
 	"github.com/hexoul/go-coinmarketcap/types"
 	"github.com/itohio/CoinWatcher/pkg/logger"
 )
 
+const cmcName = "CoinMarketCap"
+
 type coinmarketcap struct {
-	client     *cmc.Client
-	key        string
+	sync.RWMutex
+	client     *cmcClient
 	symbols    []Symbol
 	currencies []string
 	iconCache  Cache
+	listings   ListingCache
+	info       map[int]Info
+
+	loaded chan struct{}
+	err    error
 }
 
-var _ Crypto = &coinmarketcap{}
+var (
+	_ Crypto    = &coinmarketcap{}
+	_ Loader    = &coinmarketcap{}
+	_ Describer = &coinmarketcap{}
+)
 
-func NewCMC(key string, iconCache Cache) *coinmarketcap {
-	client := cmc.GetInstanceWithKey(key)
+// NewCMC creates a CoinMarketCap feed. Symbol listings are loaded in the background, see Loaded.
+// Spent API credits are reported to meter if it is not nil.
+// Listings cached in listings are used right away and refreshed in the background once they are older than ttl.
+// Zero ttl ignores the cached listings. Loading is abandoned when ctx is done.
+func NewCMC(ctx context.Context, key string, iconCache Cache, meter CreditMeter, listings ListingCache, ttl time.Duration) (*coinmarketcap, error) {
+	return newCMC(ctx, CoinMarketCapURL, key, iconCache, meter, listings, ttl)
+}
 
-	list, err := client.CryptoListingsLatest(&types.Options{
-		Limit: 1500,
-	})
+func newCMC(ctx context.Context, baseURL, key string, iconCache Cache, meter CreditMeter, listings ListingCache, ttl time.Duration) (*coinmarketcap, error) {
+	client, err := newCMCClient(baseURL, key, meter)
 	if err != nil {
-		panic(fmt.Errorf("Could not get symbol list: %v", err))
+		return nil, err
+	}
+
+	ret := &coinmarketcap{
+		client:    client,
+		iconCache: iconCache,
+		listings:  listings,
+		loaded:    make(chan struct{}),
 	}
-	symbols := make([]Symbol, len(list.CryptoMarket))
-	sStr := make([]string, len(list.CryptoMarket))
+
+	if listing, ok := ret.cached(ttl); ok {
+		ret.symbols = listing.Symbols
+		ret.currencies = listing.Currencies
+		close(ret.loaded)
+		if time.Since(listing.Updated) >= ttl {
+			go ret.refresh(ctx)
+		}
+		return ret, nil
+	}
+	go ret.load(ctx)
+
+	return ret, nil
+}
+
+func (c *coinmarketcap) cached(ttl time.Duration) (Listing, bool) {
+	if c.listings == nil || ttl <= 0 {
+		return Listing{}, false
+	}
+	listing, err := c.listings.LoadListing(cmcName)
+	if err != nil || len(listing.Symbols) == 0 {
+		return Listing{}, false
+	}
+	for i := range listing.Symbols {
+		listing.Symbols[i].iconCache = c.iconCache
+	}
+	logger.Log.Debug().Int("symbols", len(listing.Symbols)).Time("updated", listing.Updated).Msg("Using cached symbols")
+	return listing, true
+}
+
+func (c *coinmarketcap) load(ctx context.Context) {
+	defer close(c.loaded)
+
+	err := c.refresh(ctx)
+
+	c.Lock()
+	defer c.Unlock()
+	c.err = err
+}
+
+// refresh fetches symbol listings and stores them in the listing cache.
+func (c *coinmarketcap) refresh(ctx context.Context) error {
+	symbols, currencies, err := c.loadSymbols(ctx)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Could not load CoinMarketCap symbols")
+		return err
+	}
+
+	c.Lock()
+	c.symbols = symbols
+	c.currencies = currencies
+	c.Unlock()
+
+	if c.listings != nil {
+		c.listings.SaveListing(cmcName, Listing{
+			Updated:    time.Now(),
+			Symbols:    symbols,
+			Currencies: currencies,
+		})
+	}
+	return nil
+}
+
+func (c *coinmarketcap) loadSymbols(ctx context.Context) ([]Symbol, []string, error) {
+	list, err := c.client.listingsLatest(ctx, 1500)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Could not get symbol list: %w", err)
+	}
+	symbols := make([]Symbol, len(list))
+	sStr := make([]string, len(list))
 	currencies := make(map[string]struct{})
-	for i, l := range list.CryptoMarket {
+	for i, l := range list {
 		symbols[i] = Symbol{
 			Id:        l.ID,
+			Provider:  cmcName,
 			Name:      l.Name,
 			Symbol:    l.Symbol,
-			iconCache: iconCache,
+			iconCache: c.iconCache,
 		}
 		sStr[i] = fmt.Sprint(l.ID)
 
@@ -54,29 +148,23 @@ func NewCMC(key string, iconCache Cache) *coinmarketcap {
 
 	logger.Log.Debug().Int("symbols", len(sStr)).Int("currencies", len(currencies)).Msg("Fetched symbols")
 
-	info, err := cmcLoadSymbols(sStr...)
+	info, err := c.loadInfo(ctx, sStr...)
 	if err != nil {
-		panic(fmt.Errorf("Could not get symbols info: %v", err))
+		return nil, nil, fmt.Errorf("Could not get symbols info: %w", err)
 	}
 
 	for i, s := range sStr {
-		if sInfo, ok := info.CryptoInfo[s]; ok {
+		if sInfo, ok := info[s]; ok {
 			symbols[i].IconURL = sInfo.Logo
 		}
 	}
 
-	return &coinmarketcap{
-		client:     client,
-		key:        key,
-		symbols:    symbols,
-		currencies: currencyList,
-		iconCache:  iconCache,
-	}
+	return symbols, currencyList, nil
 }
 
-func cmcLoadSymbols(symbols ...string) (info *types.CryptoInfoMap, err error) {
+func (c *coinmarketcap) loadInfo(ctx context.Context, symbols ...string) (info map[string]*cmcInfo, err error) {
 	const N = 1000
-	client := cmc.GetInstance()
+	info = make(map[string]*cmcInfo, len(symbols))
 	var slice []string
 	for len(symbols) > 0 {
 		n := N
@@ -84,22 +172,15 @@ func cmcLoadSymbols(symbols ...string) (info *types.CryptoInfoMap, err error) {
 			n = len(symbols)
 		}
 		slice, symbols = symbols[:n], symbols[n:]
-		var infoTmp *types.CryptoInfoMap
-		infoTmp, err = client.CryptoInfo(&types.Options{
-			ID: strings.Join(slice, ","),
-		})
+		var infoTmp map[string]*cmcInfo
+		infoTmp, err = c.client.info(ctx, slice...)
 		if err != nil {
 			logger.Log.Error().Err(err).Str("slice", strings.Join(slice, ",")).Msg("Failed CryptoInfo")
 			return
 		}
 
-		if info == nil {
-			info = infoTmp
-			continue
-		}
-
-		for k, v := range infoTmp.CryptoInfo {
-			info.CryptoInfo[k] = v
+		for k, v := range infoTmp {
+			info[k] = v
 		}
 	}
 	logger.Log.Debug().Msg("Loaded symbols")
@@ -107,15 +188,76 @@ func cmcLoadSymbols(symbols ...string) (info *types.CryptoInfoMap, err error) {
 	return
 }
 
+// GetInfo returns metadata of symbol. It is fetched once per coin.
+func (c *coinmarketcap) GetInfo(ctx context.Context, symbol Symbol) (Info, error) {
+	c.RLock()
+	sym, ok := lookup(c.symbols, symbol)
+	info, cached := c.info[sym.Id]
+	c.RUnlock()
+	if !ok {
+		return Info{}, unknownSymbols([]Symbol{symbol})
+	}
+	if cached {
+		return info, nil
+	}
+
+	id := fmt.Sprint(sym.Id)
+	list, err := c.client.info(ctx, id)
+	if err != nil {
+		return Info{}, err
+	}
+	item, ok := list[id]
+	if !ok || item == nil {
+		return Info{}, unknownSymbols([]Symbol{symbol})
+	}
+	info = Info{
+		Symbol:      sym,
+		Description: strings.TrimSpace(item.Description),
+		Category:    item.Category,
+		Tags:        item.Tags,
+		Website:     item.Urls["website"],
+		Explorer:    item.Urls["explorer"],
+		SourceCode:  item.Urls["source_code"],
+	}
+
+	c.Lock()
+	if c.info == nil {
+		c.info = make(map[int]Info)
+	}
+	c.info[sym.Id] = info
+	c.Unlock()
+	return info, nil
+}
+
+func (c *coinmarketcap) Loaded() <-chan struct{} {
+	return c.loaded
+}
+
+func (c *coinmarketcap) Err() error {
+	c.RLock()
+	defer c.RUnlock()
+	return c.err
+}
+
+func (c *coinmarketcap) Name() string {
+	return cmcName
+}
+
 func (c *coinmarketcap) GetSymbols() []Symbol {
+	c.RLock()
+	defer c.RUnlock()
 	return c.symbols
 }
 
 func (c *coinmarketcap) GetCurrencies() []string {
+	c.RLock()
+	defer c.RUnlock()
 	return c.currencies
 }
 
 func (c *coinmarketcap) FindSymbol(symbol string) (Symbol, bool) {
+	c.RLock()
+	defer c.RUnlock()
 	for _, s := range c.symbols {
 		if s.Symbol == symbol {
 			return s, true
@@ -142,32 +284,149 @@ func cmcQ2Q(s Symbol, q types.Quote) Quote {
 	ret.Volume7D = q.Volume7D
 	ret.Volume30D = q.Volume30D
 
-	//ret.LastUpdated = time.Parse()
+	ret.LastUpdated = cmcParseTime(q.LastUpdated)
 
 	return ret
 }
 
-func (c *coinmarketcap) GetQuotes(currency string, symbol ...string) ([]Quote, error) {
-	qts, err := c.client.CryptoMarketQuotesLatest(&types.Options{
-		Symbol: strings.Join(symbol, ","),
-	})
+// resolve returns the CMC symbol of s. Symbols of this provider are trusted
+// even before the listings are loaded.
+func (c *coinmarketcap) resolve(s Symbol) (Symbol, bool) {
+	if s.Provider == cmcName && s.Id != 0 {
+		if sym, ok := c.lookup(s); ok {
+			return sym, true
+		}
+		return s, true
+	}
+	return c.lookup(s)
+}
+
+func (c *coinmarketcap) lookup(s Symbol) (Symbol, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	return lookup(c.symbols, s)
+}
+
+func (c *coinmarketcap) GetQuotes(ctx context.Context, currency string, symbol ...Symbol) ([]Quote, error) {
+	ids := make([]string, 0, len(symbol))
+	symbols := make(map[int]Symbol, len(symbol))
+	for _, s := range symbol {
+		if sym, ok := c.resolve(s); ok {
+			ids = append(ids, fmt.Sprint(sym.Id))
+			symbols[sym.Id] = sym
+		}
+	}
+	if len(ids) == 0 {
+		if len(symbol) > 0 {
+			return nil, unknownSymbols(symbol)
+		}
+		return nil, nil
+	}
+
+	qts, err := c.client.quotesLatest(ctx, "", ids...)
 	if err != nil {
-		logger.Log.Error().Err(err).Str("symbol", strings.Join(symbol, ",")).Msg("Could not get latest quotes")
+		logger.Log.Error().Err(err).Str("id", strings.Join(ids, ",")).Msg("Could not get latest quotes")
 		return nil, err
 	}
 
-	quotes := make([]Quote, 0, len(qts.CryptoMarket))
-	for s, q := range qts.CryptoMarket {
+	quotes := make([]Quote, 0, len(qts))
+	for _, q := range qts {
 		if quote, ok := q.Quote[currency]; ok {
-			if sym, ok := c.FindSymbol(s); ok {
-				quotes = append(quotes, cmcQ2Q(sym, *quote))
+			sym, ok := symbols[q.ID]
+			if !ok {
+				sym = Symbol{
+					Id:        q.ID,
+					Provider:  cmcName,
+					Name:      q.Name,
+					Symbol:    q.Symbol,
+					iconCache: c.iconCache,
+				}
 			}
+			quotes = append(quotes, cmcQ2Q(sym, *quote))
 		}
 	}
 
 	return quotes, nil
 }
 
-func (c *coinmarketcap) GetOHLCV(currency string, symbol ...string) ([]Ohlcv, error) {
-	return nil, fmt.Errorf("not implemented")
+// cmcInterval maps interval onto CMC time_period and interval options.
+func cmcInterval(interval time.Duration) (period, name string, err error) {
+	switch {
+	case interval >= time.Hour*24 && interval%(time.Hour*24) == 0:
+		return "daily", fmt.Sprintf("%dd", interval/(time.Hour*24)), nil
+	case interval >= time.Hour && interval%time.Hour == 0:
+		return "hourly", fmt.Sprintf("%dh", interval/time.Hour), nil
+	}
+	return "", "", fmt.Errorf("%w: %v", ErrInterval, interval)
+}
+
+func cmcParseTime(t string) time.Time {
+	ret, _ := time.Parse(time.RFC3339, t)
+	return ret
+}
+
+func cmcOhlcv(s Symbol, o types.Ohlcv) Ohlcv {
+	ret := Ohlcv{
+		Symbol:      s,
+		LastUpdated: cmcParseTime(o.LastUpdated),
+		TimeOpen:    cmcParseTime(o.TimeOpen),
+		TimeClose:   cmcParseTime(o.TimeClose),
+		Quote:       make(map[string]OhlcvQuote, len(o.Quote)),
+	}
+
+	for currency, q := range o.Quote {
+		if q == nil {
+			continue
+		}
+		ret.Quote[currency] = OhlcvQuote{
+			Open:        q.Open,
+			High:        q.High,
+			Low:         q.Low,
+			Close:       q.Close,
+			Volume:      q.Volume,
+			Timestamp:   cmcParseTime(q.Timestamp),
+			LastUpdated: cmcParseTime(q.LastUpdated),
+		}
+	}
+	if ret.LastUpdated.IsZero() {
+		ret.LastUpdated = ret.TimeClose
+	}
+
+	return ret
+}
+
+func (c *coinmarketcap) GetOHLCV(ctx context.Context, currency string, interval time.Duration, start, end time.Time, symbol ...Symbol) ([]Ohlcv, error) {
+	period, name, err := cmcInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []Ohlcv
+	for _, s := range symbol {
+		sym, ok := c.resolve(s)
+		if !ok {
+			continue
+		}
+
+		list, err := c.client.ohlcvHistorical(ctx, url.Values{
+			"id":          {fmt.Sprint(sym.Id)},
+			"convert":     {currency},
+			"time_period": {period},
+			"interval":    {name},
+			"time_start":  {start.UTC().Format(time.RFC3339)},
+			"time_end":    {end.UTC().Format(time.RFC3339)},
+		})
+		if err != nil {
+			logger.Log.Error().Err(err).Str("symbol", s.Symbol).Msg("Could not get historical ohlcv")
+			return nil, err
+		}
+
+		for _, o := range list.Ohlcv {
+			if o != nil {
+				ret = append(ret, cmcOhlcv(sym, *o))
+			}
+		}
+	}
+
+	return ret, nil
 }
@@ -0,0 +1,131 @@
+package crypto
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// cmcServer serves a single coin listing and counts requests.
+func cmcServer(t *testing.T) (*httptest.Server, func() int) {
+	t.Helper()
+	var (
+		lock     sync.Mutex
+		requests int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests++
+		lock.Unlock()
+
+		switch r.URL.Path {
+		case "/cryptocurrency/listings/latest":
+			w.Write([]byte(`{"status":{},"data":[{"id":1,"name":"Bitcoin","symbol":"BTC","quote":{"USD":{"price":50000}}}]}`))
+		case "/cryptocurrency/info":
+			w.Write([]byte(`{"status":{},"data":{"1":{"id":1,"logo":"https://example.com/1.png"}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return requests
+	}
+}
+
+type testListings struct {
+	listing Listing
+	saved   chan Listing
+}
+
+func (l *testListings) LoadListing(provider string) (Listing, error) {
+	return l.listing, nil
+}
+
+func (l *testListings) SaveListing(provider string, listing Listing) {
+	l.saved <- listing
+}
+
+func TestCMCListingCache(t *testing.T) {
+	srv, requests := cmcServer(t)
+	cached := []Symbol{{Id: 1027, Provider: cmcName, Name: "Ethereum", Symbol: "ETH"}}
+
+	for _, tc := range []struct {
+		name    string
+		ttl     time.Duration
+		age     time.Duration
+		cached  bool
+		refresh bool
+	}{
+		{"fresh", time.Hour, time.Minute, true, false},
+		{"stale", time.Hour, time.Hour * 2, true, true},
+		{"disabled", 0, time.Minute, false, true},
+	} {
+		listings := &testListings{
+			listing: Listing{Updated: time.Now().Add(-tc.age), Symbols: cached, Currencies: []string{"EUR"}},
+			saved:   make(chan Listing, 1),
+		}
+		before := requests()
+		c, err := newCMC(context.Background(), srv.URL, "key", nil, nil, listings, tc.ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if tc.cached {
+			select {
+			case <-c.Loaded():
+			default:
+				t.Fatalf("%s: cached listing is not loaded right away", tc.name)
+			}
+			if got := c.GetSymbols(); len(got) != 1 || got[0].Symbol != "ETH" {
+				t.Errorf("%s: got symbols %+v, want the cached ones", tc.name, got)
+			}
+		}
+
+		if !tc.refresh {
+			if n := requests() - before; n != 0 {
+				t.Errorf("%s: fresh listing is refreshed with %d requests", tc.name, n)
+			}
+			continue
+		}
+		select {
+		case saved := <-listings.saved:
+			if len(saved.Symbols) != 1 || saved.Symbols[0].Symbol != "BTC" || saved.Symbols[0].IconURL == "" {
+				t.Errorf("%s: saved symbols %+v", tc.name, saved.Symbols)
+			}
+			if len(saved.Currencies) != 1 || saved.Currencies[0] != "USD" {
+				t.Errorf("%s: saved currencies %v", tc.name, saved.Currencies)
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatalf("%s: listing is not refreshed", tc.name)
+		}
+	}
+}
+
+func TestCMCInterval(t *testing.T) {
+	for _, tc := range []struct {
+		interval     time.Duration
+		period, name string
+		err          error
+	}{
+		{time.Hour, "hourly", "1h", nil},
+		{time.Hour * 6, "hourly", "6h", nil},
+		{time.Hour * 24, "daily", "1d", nil},
+		{time.Hour * 24 * 7, "daily", "7d", nil},
+		{time.Hour * 36, "hourly", "36h", nil},
+		{time.Minute * 5, "", "", ErrInterval},
+		{time.Minute * 90, "", "", ErrInterval},
+		{0, "", "", ErrInterval},
+	} {
+		period, name, err := cmcInterval(tc.interval)
+		if period != tc.period || name != tc.name || !errors.Is(err, tc.err) {
+			t.Errorf("%v: got %q, %q, %v, want %q, %q, %v", tc.interval, period, name, err, tc.period, tc.name, tc.err)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package crypto
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/itohio/CoinWatcher/pkg/logger"
+)
+
+const (
+	// fxTTL is how long fetched exchange rates are used before they are refreshed.
+	fxTTL = time.Hour
+	// coinTTL is how long quotes of coins used as currencies are used before they are refreshed.
+	coinTTL = time.Minute
+)
+
+var (
+	// usdPegged are stablecoins converted at the exchange rate of USD.
+	usdPegged = []string{"USDT", "USDC", "BUSD"}
+	// coinCurrencies are coins offered as currencies if the feed lists them.
+	coinCurrencies = []string{"BTC", "ETH"}
+)
+
+// coinRate is a fetched quote of a coin that is used as a currency.
+type coinRate struct {
+	base    string
+	price   float64
+	updated time.Time
+}
+
+type converter struct {
+	Crypto
+	rates   RateSource
+	timeout time.Duration
+
+	// fetch serializes fetching rates, lock guards the fetched table
+	fetch   sync.Mutex
+	lock    sync.Mutex
+	table   map[string]float64
+	updated time.Time
+	coins   map[string]coinRate
+}
+
+type streamConverter struct {
+	*converter
+	streamer Streamer
+}
+
+var (
+	_ Crypto    = &converter{}
+	_ Describer = &converter{}
+	_ Streamer  = &streamConverter{}
+)
+
+// NewConverter serves quotes in any fiat currency known to rates by converting quotes in USD,
+// or another currency that feed supports natively. USD stablecoins are converted at the rate of USD.
+// Quotes in a coin that feed lists are converted at the price of that coin.
+// Percent changes are not adjusted for exchange rate moves.
+// Rates are fetched right away and whenever they expire, each fetch is bounded by timeout unless it is zero.
+// The result is a Streamer if feed is.
+func NewConverter(ctx context.Context, feed Crypto, rates RateSource, timeout time.Duration) Crypto {
+	ret := &converter{
+		Crypto:  feed,
+		rates:   rates,
+		timeout: timeout,
+		coins:   make(map[string]coinRate),
+	}
+	// Currencies are listed from fetched rates only
+	if _, err := ret.fxRates(ctx); err != nil {
+		logger.Log.Error().Err(err).Str("source", rates.Name()).Msg("Could not get exchange rates")
+	}
+	if streamer, ok := feed.(Streamer); ok {
+		return &streamConverter{
+			converter: ret,
+			streamer:  streamer,
+		}
+	}
+	return ret
+}
+
+// cached returns the last fetched rates and when they were fetched.
+func (c *converter) cached() (map[string]float64, time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.table, c.updated
+}
+
+func (c *converter) fxRates(ctx context.Context) (map[string]float64, error) {
+	if table, updated := c.cached(); table != nil && time.Since(updated) < fxTTL {
+		return table, nil
+	}
+	c.fetch.Lock()
+	defer c.fetch.Unlock()
+	// Rates may have been fetched while waiting
+	if table, updated := c.cached(); table != nil && time.Since(updated) < fxTTL {
+		return table, nil
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	table, err := c.rates.Rates(ctx)
+	if err != nil {
+		if stale, _ := c.cached(); stale != nil {
+			logger.Log.Warn().Err(err).Str("source", c.rates.Name()).Msg("Using stale exchange rates")
+			return stale, nil
+		}
+		return nil, err
+	}
+	c.lock.Lock()
+	c.table = table
+	c.updated = time.Now()
+	c.lock.Unlock()
+	return table, nil
+}
+
+// native reports whether the feed itself quotes in currency.
+func (c *converter) native(currency string) bool {
+	for _, q := range c.Crypto.GetCurrencies() {
+		if q == currency {
+			return true
+		}
+	}
+	return false
+}
+
+// rate returns the exchange rate of currency in table.
+func rate(table map[string]float64, currency string) (float64, bool) {
+	if r, ok := table[currency]; ok {
+		return r, true
+	}
+	for _, q := range usdPegged {
+		if q == currency {
+			r, ok := table["USD"]
+			return r, ok
+		}
+	}
+	return 0, false
+}
+
+// base picks a native currency with a known exchange rate, preferring USD and then USD stablecoins.
+func (c *converter) base(table map[string]float64) (string, bool) {
+	for _, q := range append([]string{"USD"}, usdPegged...) {
+		if _, ok := rate(table, q); ok && c.native(q) {
+			return q, true
+		}
+	}
+	for _, q := range c.Crypto.GetCurrencies() {
+		if _, ok := rate(table, q); ok {
+			return q, true
+		}
+	}
+	return "", false
+}
+
+// source picks a native currency to convert into currency and returns the exchange rate.
+func (c *converter) source(ctx context.Context, currency string) (string, float64, error) {
+	table, err := c.fxRates(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	from, ok := c.base(table)
+	if !ok {
+		return "", 0, fmt.Errorf("%s has no currency to convert to %s", c.Name(), currency)
+	}
+	if to, ok := rate(table, currency); ok {
+		r, _ := rate(table, from)
+		return from, to / r, nil
+	}
+	price, err := c.coinPrice(ctx, from, currency)
+	if err != nil {
+		return "", 0, err
+	}
+	return from, 1 / price, nil
+}
+
+// coinPrice returns the price in base of the coin with the ticker currency.
+func (c *converter) coinPrice(ctx context.Context, base, currency string) (float64, error) {
+	c.lock.Lock()
+	cached, ok := c.coins[currency]
+	c.lock.Unlock()
+	if ok && cached.base == base && time.Since(cached.updated) < coinTTL {
+		return cached.price, nil
+	}
+
+	symbol, ok := c.Crypto.FindSymbol(currency)
+	if !ok {
+		return 0, fmt.Errorf("unknown currency %s", currency)
+	}
+	quotes, err := c.Crypto.GetQuotes(ctx, base, symbol)
+	if err != nil {
+		return 0, err
+	}
+	if len(quotes) == 0 || quotes[0].Price <= 0 {
+		return 0, fmt.Errorf("%s has no price of %s in %s", c.Name(), currency, base)
+	}
+	c.lock.Lock()
+	c.coins[currency] = coinRate{base: base, price: quotes[0].Price, updated: time.Now()}
+	c.lock.Unlock()
+	return quotes[0].Price, nil
+}
+
+// GetCurrencies lists native currencies, currencies of the last fetched rates and coinCurrencies
+// without fetching them.
+func (c *converter) GetCurrencies() []string {
+	currencies := c.Crypto.GetCurrencies()
+	table, _ := c.cached()
+	if table == nil {
+		return currencies
+	}
+
+	seen := make(map[string]struct{}, len(currencies)+len(table))
+	ret := make([]string, 0, len(currencies)+len(table))
+	for _, q := range currencies {
+		seen[q] = struct{}{}
+		ret = append(ret, q)
+	}
+	if _, ok := c.base(table); !ok {
+		return ret
+	}
+	for q := range table {
+		if _, ok := seen[q]; !ok {
+			seen[q] = struct{}{}
+			ret = append(ret, q)
+		}
+	}
+	for _, q := range coinCurrencies {
+		if _, ok := seen[q]; ok {
+			continue
+		}
+		if _, ok := c.Crypto.FindSymbol(q); ok {
+			ret = append(ret, q)
+		}
+	}
+	sort.Sort(sort.StringSlice(ret))
+	return ret
+}
+
+func convertQuote(q Quote, rate float64) Quote {
+	q.Price *= rate
+	q.MarketCap *= rate
+	q.Volume24H *= rate
+	q.Volume7D *= rate
+	q.Volume30D *= rate
+	q.Volume24Hquote *= rate
+	return q
+}
+
+func (c *converter) GetQuotes(ctx context.Context, currency string, symbol ...Symbol) ([]Quote, error) {
+	if c.native(currency) {
+		return c.Crypto.GetQuotes(ctx, currency, symbol...)
+	}
+	from, rate, err := c.source(ctx, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes, err := c.Crypto.GetQuotes(ctx, from, symbol...)
+	for i := range quotes {
+		quotes[i] = convertQuote(quotes[i], rate)
+	}
+	return quotes, err
+}
+
+func (c *converter) GetOHLCV(ctx context.Context, currency string, interval time.Duration, start, end time.Time, symbol ...Symbol) ([]Ohlcv, error) {
+	if c.native(currency) {
+		return c.Crypto.GetOHLCV(ctx, currency, interval, start, end, symbol...)
+	}
+	from, rate, err := c.source(ctx, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	candles, err := c.Crypto.GetOHLCV(ctx, from, interval, start, end, symbol...)
+	for i, o := range candles {
+		q, ok := o.Quote[from]
+		if !ok {
+			continue
+		}
+		q.Open *= rate
+		q.High *= rate
+		q.Low *= rate
+		q.Close *= rate
+		q.Volume *= rate
+		candles[i].Quote = map[string]OhlcvQuote{currency: q}
+	}
+	return candles, err
+}
+
+func (c *converter) GetInfo(ctx context.Context, symbol Symbol) (Info, error) {
+	return describe(ctx, symbol, c.Crypto)
+}
+
+func (c *streamConverter) Subscribe(ctx context.Context, currency string, symbol ...Symbol) (<-chan Quote, error) {
+	if c.native(currency) {
+		return c.streamer.Subscribe(ctx, currency, symbol...)
+	}
+	from, _, err := c.source(ctx, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes, err := c.streamer.Subscribe(ctx, from, symbol...)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(chan Quote)
+	go func() {
+		defer close(ret)
+		for q := range quotes {
+			_, rate, err := c.source(ctx, currency)
+			if err != nil {
+				logger.Log.Error().Err(err).Msg("Could not convert streamed quote")
+				continue
+			}
+			select {
+			case ret <- convertQuote(q, rate):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ret, nil
+}
@@ -0,0 +1,209 @@
+package crypto
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) <= math.Abs(b)*1e-9
+}
+
+func TestConverterQuotes(t *testing.T) {
+	srv := ecbServer(t)
+	feed := newUSDFeed()
+	conv := NewConverter(context.Background(), feed, NewECB(srv.URL+"/eurofxref-daily.xml"), time.Second)
+	btc := Symbol{Symbol: "BTC"}
+
+	quotes, err := conv.GetQuotes(context.Background(), "GBP", btc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// USD -> EUR -> GBP: 43800 / 1.095 * 0.861
+	q := quotes[0]
+	if !near(q.Price, 34440) || !near(q.MarketCap, 876e9/1.095*0.861) || !near(q.Volume24H, 21.9e9/1.095*0.861) ||
+		!near(q.Volume24Hquote, 21.9e9/1.095*0.861) {
+		t.Fatalf("unexpected conversion %+v", q)
+	}
+	if q.Volume24Hbase != 500000 || q.PercentChange24H != 1.5 {
+		t.Fatalf("base volume and percent changes are converted: %+v", q)
+	}
+
+	quotes, err = conv.GetQuotes(context.Background(), "EUR", btc)
+	if err != nil || !near(quotes[0].Price, 40000) {
+		t.Fatalf("unexpected EUR quote %v %v", quotes, err)
+	}
+	if quotes, err = conv.GetQuotes(context.Background(), "BTC", btc); err != nil || quotes[0].Price != 1 {
+		t.Fatalf("native currency is converted: %v %v", quotes, err)
+	}
+	if got := strings.Join(feed.requested, ","); got != "USD,USD,BTC" {
+		t.Fatalf("feed was asked for %s", got)
+	}
+
+	candles, err := conv.GetOHLCV(context.Background(), "JPY", time.Hour, time.Now().Add(-time.Hour), time.Now(), btc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, ok := candles[0].Quote["JPY"]
+	if !ok || len(candles[0].Quote) != 1 {
+		t.Fatalf("candle is not quoted in JPY: %+v", candles[0].Quote)
+	}
+	if rate := 155.48 / 1.095; !near(c.Close, 43800*rate) || !near(c.High, 43800*1.1*rate) || !near(c.Volume, 21.9e9*rate) {
+		t.Fatalf("unexpected candle %+v", c)
+	}
+
+	if _, err := conv.GetQuotes(context.Background(), "XYZ", btc); err == nil {
+		t.Fatal("expected an error for an unknown currency")
+	}
+}
+
+func TestConverterCurrencies(t *testing.T) {
+	srv := ecbServer(t)
+	conv := NewConverter(context.Background(), newUSDFeed(), NewECB(srv.URL+"/eurofxref-daily.xml"), time.Second)
+
+	currencies := conv.GetCurrencies()
+	if !sort.StringsAreSorted(currencies) || strings.Join(currencies, ",") != "BTC,CHF,EUR,GBP,JPY,PLN,USD" {
+		t.Fatalf("unexpected currencies %v", currencies)
+	}
+
+	// Without a currency that has an exchange rate nothing can be converted
+	xyz := &testFeed{name: "test", currencies: []string{"XYZ"}, quotes: map[string][]Quote{"XYZ": nil}}
+	if got := NewConverter(context.Background(), xyz, NewECB(srv.URL+"/eurofxref-daily.xml"), time.Second).GetCurrencies(); strings.Join(got, ",") != "XYZ" {
+		t.Fatalf("unexpected currencies %v", got)
+	}
+}
+
+func TestConverterStablecoinBase(t *testing.T) {
+	srv := ecbServer(t)
+	btc := Symbol{Provider: "test", Id: 1, Symbol: "BTC"}
+	// GBP has an exchange rate too, but the feed quotes coins in stablecoins
+	feed := &testFeed{
+		name:       "test",
+		currencies: []string{"BTC", "GBP", "USDT"},
+		quotes: map[string][]Quote{
+			"BTC":  {{Symbol: btc, Price: 1}},
+			"USDT": {{Symbol: btc, Price: 43800}},
+		},
+	}
+	conv := NewConverter(context.Background(), feed, NewECB(srv.URL+"/eurofxref-daily.xml"), time.Second)
+
+	quotes, err := conv.GetQuotes(context.Background(), "EUR", btc)
+	if err != nil || !near(quotes[0].Price, 40000) {
+		t.Fatalf("unexpected EUR quote %v %v", quotes, err)
+	}
+	if got := strings.Join(feed.requested, ","); got != "USDT" {
+		t.Fatalf("feed was asked for %s", got)
+	}
+	if got := strings.Join(conv.GetCurrencies(), ","); got != "BTC,CHF,EUR,GBP,JPY,PLN,USD,USDT" {
+		t.Fatalf("unexpected currencies %v", got)
+	}
+}
+
+func TestConverterCoinCurrency(t *testing.T) {
+	srv := ecbServer(t)
+	btc := Symbol{Provider: "test", Id: 1, Symbol: "BTC"}
+	eth := Symbol{Provider: "test", Id: 2, Symbol: "ETH"}
+	feed := &testFeed{
+		name:       "test",
+		currencies: []string{"USD"},
+		quotes: map[string][]Quote{
+			"USD": {{Symbol: btc, Price: 43800}, {Symbol: eth, Price: 2190, MarketCap: 219e9}},
+		},
+	}
+	conv := NewConverter(context.Background(), feed, NewECB(srv.URL+"/eurofxref-daily.xml"), time.Second)
+
+	// ETH in BTC is converted at the price of BTC in USD
+	for i := 0; i < 2; i++ {
+		quotes, err := conv.GetQuotes(context.Background(), "BTC", eth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q := quotes[0]; !near(q.Price, 0.05) || !near(q.MarketCap, 5e6) {
+			t.Fatalf("unexpected BTC quote %+v", q)
+		}
+	}
+	// The price of BTC is fetched once while it is fresh
+	if got := strings.Join(feed.requested, ","); got != "USD,USD,USD" {
+		t.Fatalf("feed was asked for %s", got)
+	}
+
+	if got := strings.Join(conv.GetCurrencies(), ","); got != "BTC,CHF,ETH,EUR,GBP,JPY,PLN,USD" {
+		t.Fatalf("unexpected currencies %v", got)
+	}
+	if _, err := conv.GetQuotes(context.Background(), "DOGE", eth); err == nil {
+		t.Fatal("expected an error for a currency that is neither fiat nor a listed coin")
+	}
+}
+
+// slowRates counts fetches and blocks each one until its context is done.
+type slowRates struct {
+	lock    sync.Mutex
+	fetches int
+}
+
+func (r *slowRates) Name() string {
+	return "slow"
+}
+
+func (r *slowRates) Rates(ctx context.Context) (map[string]float64, error) {
+	r.lock.Lock()
+	r.fetches++
+	r.lock.Unlock()
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestConverterTimeout(t *testing.T) {
+	rates := &slowRates{}
+	start := time.Now()
+	conv := NewConverter(context.Background(), newUSDFeed(), rates, time.Millisecond*20)
+	if _, err := conv.GetQuotes(context.Background(), "EUR", Symbol{Symbol: "BTC"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the fetch to time out, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("fetching rates is not bounded by the timeout: %v", elapsed)
+	}
+
+	// Listing currencies never fetches
+	if got := strings.Join(conv.GetCurrencies(), ","); got != "BTC,USD" {
+		t.Fatalf("unexpected currencies %v", got)
+	}
+	if rates.fetches != 2 {
+		t.Fatalf("rates were fetched %d times", rates.fetches)
+	}
+}
+
+func TestConverterStaleRates(t *testing.T) {
+	srv := ecbServer(t)
+	url := srv.URL + "/eurofxref-daily.xml"
+	conv := NewConverter(context.Background(), newUSDFeed(), NewECB(url), time.Second).(*converter)
+
+	if _, err := conv.GetQuotes(context.Background(), "EUR", Symbol{Symbol: "BTC"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// An outage after rates expire keeps the last table
+	conv.rates = NewECB(srv.URL + "/missing.xml")
+	conv.updated = time.Now().Add(-fxTTL * 2)
+	quotes, err := conv.GetQuotes(context.Background(), "EUR", Symbol{Symbol: "BTC"})
+	if err != nil || !near(quotes[0].Price, 40000) {
+		t.Fatalf("stale rates are not used: %v %v", quotes, err)
+	}
+
+	fresh := NewConverter(context.Background(), newUSDFeed(), NewECB(srv.URL+"/missing.xml"), time.Second)
+	if _, err := fresh.GetQuotes(context.Background(), "EUR", Symbol{Symbol: "BTC"}); err == nil {
+		t.Fatal("expected an error without exchange rates")
+	}
+
+	failing := newUSDFeed()
+	failing.err = ErrRateLimited
+	if _, err := NewConverter(context.Background(), failing, NewECB(url), time.Second).GetQuotes(context.Background(), "EUR", Symbol{Symbol: "BTC"}); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("feed error is lost: %v", err)
+	}
+}
@@ -1,9 +1,70 @@
 package crypto
 
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Crypto is a price feed. Methods that call the provider take a context that cancels the call
+// and bounds its duration, listings are kept in memory.
 type Crypto interface {
+	Name() string
 	FindSymbol(symbol string) (Symbol, bool)
 	GetSymbols() []Symbol
 	GetCurrencies() []string
-	GetQuotes(currency string, symbol ...string) ([]Quote, error)
-	GetOHLCV(currency string, symbol ...string) ([]Ohlcv, error)
+	GetQuotes(ctx context.Context, currency string, symbol ...Symbol) ([]Quote, error)
+	GetOHLCV(ctx context.Context, currency string, interval time.Duration, start, end time.Time, symbol ...Symbol) ([]Ohlcv, error)
+}
+
+// Streamer is implemented by providers that can push quotes as soon as they change.
+// The returned channel is closed when ctx is done.
+type Streamer interface {
+	Subscribe(ctx context.Context, currency string, symbol ...Symbol) (<-chan Quote, error)
+}
+
+// Loader is implemented by providers that load their symbol listings in the background.
+// Loaded is closed once loading is finished and Err tells whether it failed.
+type Loader interface {
+	Loaded() <-chan struct{}
+	Err() error
+}
+
+// CreditMeter is notified about API credits spent by providers that charge per call.
+type CreditMeter interface {
+	AddCredits(provider string, credits int)
+}
+
+// Describer is implemented by providers that have coin metadata. ErrUnsupported is returned
+// when neither the provider nor the providers it wraps have any.
+type Describer interface {
+	GetInfo(ctx context.Context, symbol Symbol) (Info, error)
+}
+
+// describe gets metadata of symbol from the first of providers that has it. The provider that listed symbol is asked first.
+func describe(ctx context.Context, symbol Symbol, providers ...Crypto) (Info, error) {
+	ordered := make([]Crypto, 0, len(providers))
+	for _, p := range providers {
+		if p.Name() == symbol.Provider {
+			ordered = append([]Crypto{p}, ordered...)
+		} else {
+			ordered = append(ordered, p)
+		}
+	}
+
+	err := ErrUnsupported
+	for _, p := range ordered {
+		d, ok := p.(Describer)
+		if !ok {
+			continue
+		}
+		info, e := d.GetInfo(ctx, symbol)
+		if e == nil {
+			return info, nil
+		}
+		if err == ErrUnsupported || !errors.Is(e, ErrUnsupported) {
+			err = e
+		}
+	}
+	return Info{}, err
 }
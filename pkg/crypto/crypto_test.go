@@ -0,0 +1,101 @@
+package crypto
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// testFeed serves canned quotes by currency and records the currencies it was asked for.
+type testFeed struct {
+	name       string
+	currencies []string
+	quotes     map[string][]Quote
+	err        error
+
+	lock      sync.Mutex
+	requested []string
+}
+
+var _ Crypto = &testFeed{}
+
+func (f *testFeed) Name() string {
+	return f.name
+}
+
+func (f *testFeed) FindSymbol(symbol string) (Symbol, bool) {
+	for _, s := range f.GetSymbols() {
+		if s.Symbol == symbol {
+			return s, true
+		}
+	}
+	return Symbol{}, false
+}
+
+func (f *testFeed) GetSymbols() []Symbol {
+	var ret []Symbol
+	for _, q := range f.quotes[f.currencies[0]] {
+		ret = append(ret, q.Symbol)
+	}
+	return ret
+}
+
+func (f *testFeed) GetCurrencies() []string {
+	return f.currencies
+}
+
+func (f *testFeed) GetQuotes(ctx context.Context, currency string, symbol ...Symbol) ([]Quote, error) {
+	f.lock.Lock()
+	f.requested = append(f.requested, currency)
+	f.lock.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	var ret []Quote
+	for _, s := range symbol {
+		for _, q := range f.quotes[currency] {
+			if q.Symbol.Symbol == s.Symbol {
+				ret = append(ret, q)
+			}
+		}
+	}
+	if len(ret) == 0 {
+		return nil, unknownSymbols(symbol)
+	}
+	return ret, nil
+}
+
+func (f *testFeed) GetOHLCV(ctx context.Context, currency string, interval time.Duration, start, end time.Time, symbol ...Symbol) ([]Ohlcv, error) {
+	quotes, err := f.GetQuotes(ctx, currency, symbol...)
+	var ret []Ohlcv
+	for _, q := range quotes {
+		ret = append(ret, Ohlcv{
+			Symbol:   q.Symbol,
+			TimeOpen: start,
+			Quote: map[string]OhlcvQuote{currency: {
+				Open: q.Price, High: q.Price * 1.1, Low: q.Price * 0.9, Close: q.Price, Volume: q.Volume24H,
+			}},
+		})
+	}
+	return ret, err
+}
+
+func newUSDFeed() *testFeed {
+	return &testFeed{
+		name:       "test",
+		currencies: []string{"BTC", "USD"},
+		quotes: map[string][]Quote{
+			"USD": {{
+				Symbol:           Symbol{Provider: "test", Id: 1, Symbol: "BTC"},
+				Price:            43800,
+				MarketCap:        876e9,
+				Volume24H:        21.9e9,
+				Volume24Hbase:    500000,
+				Volume24Hquote:   21.9e9,
+				PercentChange24H: 1.5,
+			}},
+			"BTC": {{Symbol: Symbol{Provider: "test", Id: 1, Symbol: "BTC"}, Price: 1}},
+		},
+	}
+}
@@ -0,0 +1,307 @@
+package crypto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/itohio/CoinWatcher/pkg/logger"
+)
+
+const dexName = "DEX"
+
+// DEXPair is a Uniswap V2 compatible pair contract that trades Token.
+type DEXPair struct {
+	Token string `json:"token"`
+	Pair  string `json:"pair"`
+}
+
+// DEXConfig configures the on-chain DEX feed.
+type DEXConfig struct {
+	// RPC is an Ethereum JSON-RPC endpoint
+	RPC string `json:"rpc,omitempty"`
+	// Reference prices the token other pairs trade against, e.g. WETH in the WETH/USDC pair.
+	// The other token of the reference pair stands for Currency.
+	Reference DEXPair `json:"reference"`
+	// Currency is the fiat currency of the reference pair, USD if empty
+	Currency string `json:"currency,omitempty"`
+	// Pairs trade tokens against the reference token or directly against the fiat token
+	Pairs []DEXPair `json:"pairs,omitempty"`
+}
+
+type dexPair struct {
+	pair       string
+	symbol     Symbol
+	quote      string
+	tokenFirst bool
+	decimals   int
+	quoteDec   int
+}
+
+type dex struct {
+	url       string
+	currency  string
+	stable    string
+	reference *dexPair
+	pairs     []*dexPair
+	symbols   []Symbol
+}
+
+var _ Crypto = &dex{}
+
+// NewDEX creates a feed that derives prices from reserves of Uniswap V2 compatible pairs read with eth_call.
+// Prices of tokens traded against the reference token are converted to fiat through the reference pair.
+func NewDEX(ctx context.Context, config DEXConfig) (*dex, error) {
+	if config.RPC == "" {
+		return nil, errors.New("no RPC url")
+	}
+	if config.Currency == "" {
+		config.Currency = "USD"
+	}
+	ret := &dex{
+		url:      config.RPC,
+		currency: strings.ToUpper(config.Currency),
+	}
+
+	configured := append([]DEXPair{config.Reference}, config.Pairs...)
+	for _, p := range configured {
+		if !ethAddress.MatchString(p.Token) || !ethAddress.MatchString(p.Pair) {
+			return nil, fmt.Errorf("bad token or pair address %s/%s", p.Token, p.Pair)
+		}
+	}
+
+	calls := make([]ethCall, 0, len(configured)*2)
+	for _, p := range configured {
+		calls = append(calls, ethCall{To: p.Pair, Data: selToken0}, ethCall{To: p.Pair, Data: selToken1})
+	}
+	results, err := ethCalls(ctx, ret.url, calls)
+	if err != nil {
+		return nil, fmt.Errorf("Could not get pair tokens: %v", err)
+	}
+
+	seen := make(map[string]struct{})
+	for i, p := range configured {
+		token := strings.ToLower(p.Token)
+		if _, ok := seen[token]; ok {
+			continue
+		}
+		seen[token] = struct{}{}
+
+		token0, err0 := abiAddress(results[i*2], 0)
+		token1, err1 := abiAddress(results[i*2+1], 0)
+		if err0 != nil || err1 != nil {
+			return nil, fmt.Errorf("%s is not a pair contract", p.Pair)
+		}
+		pair := &dexPair{
+			pair: strings.ToLower(p.Pair),
+		}
+		switch token {
+		case token0:
+			pair.tokenFirst, pair.quote = true, token1
+		case token1:
+			pair.quote = token0
+		default:
+			return nil, fmt.Errorf("pair %s does not trade %s", p.Pair, p.Token)
+		}
+		pair.symbol = Symbol{
+			Id:       idFromString(token),
+			Provider: dexName,
+			Symbol:   token,
+		}
+
+		if ret.reference == nil {
+			ret.reference = pair
+			ret.stable = pair.quote
+		} else if pair.quote != ret.stable && pair.quote != ret.reference.symbol.Symbol {
+			return nil, fmt.Errorf("pair %s trades against %s instead of the reference or fiat token", p.Pair, pair.quote)
+		}
+		ret.pairs = append(ret.pairs, pair)
+	}
+
+	if err := ret.loadTokens(ctx); err != nil {
+		return nil, err
+	}
+
+	logger.Log.Debug().Int("symbols", len(ret.symbols)).Str("rpc", ret.url).Msg("Loaded DEX pairs")
+
+	return ret, nil
+}
+
+// loadTokens fetches decimals of the traded tokens, then their tickers and names. Tokens that do not
+// implement the optional symbol or name calls keep their addresses.
+func (c *dex) loadTokens(ctx context.Context) error {
+	calls := make([]ethCall, 0, len(c.pairs)*2)
+	for _, p := range c.pairs {
+		calls = append(calls,
+			ethCall{To: p.symbol.Symbol, Data: selDecimals},
+			ethCall{To: p.quote, Data: selDecimals},
+		)
+	}
+	results, err := ethCalls(ctx, c.url, calls)
+	if err != nil {
+		return fmt.Errorf("Could not get token details: %v", err)
+	}
+	for i, p := range c.pairs {
+		decimals, err := abiUint(results[i*2], 0)
+		if err != nil {
+			return fmt.Errorf("%s decimals: %v", p.symbol.Symbol, err)
+		}
+		quoteDec, err := abiUint(results[i*2+1], 0)
+		if err != nil {
+			return fmt.Errorf("%s decimals: %v", p.quote, err)
+		}
+		p.decimals = int(decimals.Int64())
+		p.quoteDec = int(quoteDec.Int64())
+	}
+
+	calls = calls[:0]
+	for _, p := range c.pairs {
+		calls = append(calls,
+			ethCall{To: p.symbol.Symbol, Data: selSymbol},
+			ethCall{To: p.symbol.Symbol, Data: selName},
+		)
+	}
+	results, errs, err := ethBatch(ctx, c.url, calls)
+	if err != nil {
+		logger.Log.Warn().Err(err).Str("rpc", c.url).Msg("Could not get token names")
+	}
+	for i, p := range c.pairs {
+		if err == nil && errs[i*2] == nil {
+			if ticker, err := abiString(results[i*2]); err == nil && ticker != "" {
+				p.symbol.Symbol = strings.ToUpper(ticker)
+			}
+		}
+		if err == nil && errs[i*2+1] == nil {
+			if name, err := abiString(results[i*2+1]); err == nil && name != "" {
+				p.symbol.Name = name
+			}
+		}
+		c.symbols = append(c.symbols, p.symbol)
+	}
+	return nil
+}
+
+func (c *dex) Name() string {
+	return dexName
+}
+
+func (c *dex) GetSymbols() []Symbol {
+	return c.symbols
+}
+
+func (c *dex) GetCurrencies() []string {
+	return []string{c.currency}
+}
+
+func (c *dex) FindSymbol(symbol string) (Symbol, bool) {
+	for _, s := range c.symbols {
+		if s.Symbol == symbol {
+			return s, true
+		}
+	}
+	return Symbol{}, false
+}
+
+func (c *dex) find(s Symbol) (*dexPair, bool) {
+	sym, ok := lookup(c.symbols, s)
+	if !ok {
+		return nil, false
+	}
+	for _, p := range c.pairs {
+		if p.symbol.Id == sym.Id {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// price returns the price of the pair token in the quote token from reserves returned by getReserves.
+func (p *dexPair) price(reserves []byte) (float64, error) {
+	r0, err := abiUint(reserves, 0)
+	if err != nil {
+		return 0, err
+	}
+	r1, err := abiUint(reserves, 1)
+	if err != nil {
+		return 0, err
+	}
+	token, quote := r1, r0
+	if p.tokenFirst {
+		token, quote = r0, r1
+	}
+	if token.Sign() == 0 || quote.Sign() == 0 {
+		return 0, fmt.Errorf("pair %s has no liquidity", p.pair)
+	}
+
+	// Reserves are in the smallest token units
+	price := new(big.Float).Quo(new(big.Float).SetInt(quote), new(big.Float).SetInt(token))
+	if p.decimals > p.quoteDec {
+		price.Mul(price, pow10(p.decimals-p.quoteDec))
+	} else {
+		price.Quo(price, pow10(p.quoteDec-p.decimals))
+	}
+	f, _ := price.Float64()
+	return f, nil
+}
+
+func pow10(n int) *big.Float {
+	return new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil))
+}
+
+func (c *dex) GetQuotes(ctx context.Context, currency string, symbol ...Symbol) ([]Quote, error) {
+	if currency != c.currency {
+		return nil, fmt.Errorf("%s only quotes in %s", dexName, c.currency)
+	}
+
+	var pairs []*dexPair
+	for _, s := range symbol {
+		if p, ok := c.find(s); ok {
+			pairs = append(pairs, p)
+		}
+	}
+	if len(pairs) == 0 && len(symbol) > 0 {
+		return nil, unknownSymbols(symbol)
+	}
+
+	calls := []ethCall{{To: c.reference.pair, Data: selGetReserves}}
+	for _, p := range pairs {
+		calls = append(calls, ethCall{To: p.pair, Data: selGetReserves})
+	}
+	results, err := ethCalls(ctx, c.url, calls)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Could not get reserves")
+		return nil, err
+	}
+
+	reference, err := c.reference.price(results[0])
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	quotes := make([]Quote, 0, len(pairs))
+	for i, p := range pairs {
+		price, err := p.price(results[i+1])
+		if err != nil {
+			logger.Log.Warn().Err(err).Str("symbol", p.symbol.Symbol).Msg("Could not price token")
+			continue
+		}
+		if p.quote != c.stable {
+			price *= reference
+		}
+		quotes = append(quotes, Quote{
+			Symbol:      p.symbol,
+			Price:       price,
+			LastUpdated: now,
+		})
+	}
+
+	return quotes, nil
+}
+
+func (c *dex) GetOHLCV(ctx context.Context, currency string, interval time.Duration, start, end time.Time, symbol ...Symbol) ([]Ohlcv, error) {
+	return nil, fmt.Errorf("%w: %s has no price history", ErrUnsupported, dexName)
+}
@@ -0,0 +1,273 @@
+package crypto
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	testUSDC     = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	testWETH     = "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+	testUNI      = "0x1f9840a85d5aF5bf1D1762F925BDADdC4201F984"
+	testWBTC     = "0x2260FAC5E5542a773Aa44fBCfeDf7C193bc2C599"
+	testDAI      = "0x6B175474E89094C44Da98b954EedeAC495271d0F"
+	testUSDCWETH = "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+	testUNIWETH  = "0xd3d2E2692501A5c9Ca623199D38826e513033a17"
+	testWBTCWETH = "0xBb2b8038a1640196FbE3e38816F3e67Cba72D940"
+	testDAIUSDC  = "0xAE461cA67B15dc8dc81CE7615e0320dA1A9aB8D5"
+)
+
+func abiEncodeUint(v *big.Int) string {
+	return fmt.Sprintf("%064x", v)
+}
+
+func abiEncodeAddress(a string) string {
+	return strings.Repeat("0", 24) + strings.ToLower(strings.TrimPrefix(a, "0x"))
+}
+
+func abiEncodeString(s string) string {
+	data := hex.EncodeToString([]byte(s))
+	if pad := len(data) % 64; pad > 0 {
+		data += strings.Repeat("0", 64-pad)
+	}
+	return abiEncodeUint(big.NewInt(32)) + abiEncodeUint(big.NewInt(int64(len(s)))) + data
+}
+
+func units(v string) *big.Int {
+	ret, ok := new(big.Int).SetString(v, 10)
+	if !ok {
+		panic(v)
+	}
+	return ret
+}
+
+// dexChain answers eth_call batches from canned contract state and records the size of each batch.
+type dexChain struct {
+	sync.Mutex
+	state   map[string]map[string]string
+	batches []int
+}
+
+func (c *dexChain) token(address, symbol, name string, decimals int64) {
+	c.state[strings.ToLower(address)] = map[string]string{
+		selDecimals: abiEncodeUint(big.NewInt(decimals)),
+		selSymbol:   abiEncodeString(symbol),
+		selName:     abiEncodeString(name),
+	}
+}
+
+func (c *dexChain) pair(address, token0, token1 string, reserve0, reserve1 *big.Int) {
+	c.state[strings.ToLower(address)] = map[string]string{
+		selToken0:      abiEncodeAddress(token0),
+		selToken1:      abiEncodeAddress(token1),
+		selGetReserves: abiEncodeUint(reserve0) + abiEncodeUint(reserve1) + abiEncodeUint(big.NewInt(1704189600)),
+	}
+}
+
+func (c *dexChain) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var batch []struct {
+		ID     int               `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"parse error"}}`))
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	c.batches = append(c.batches, len(batch))
+	responses := make([]map[string]interface{}, 0, len(batch))
+	for _, req := range batch {
+		var call ethCall
+		if req.Method == "eth_call" && len(req.Params) > 0 {
+			json.Unmarshal(req.Params[0], &call)
+		}
+		result, ok := c.state[strings.ToLower(call.To)][call.Data]
+		if !ok {
+			responses = append(responses, map[string]interface{}{
+				"jsonrpc": "2.0", "id": req.ID,
+				"error": map[string]interface{}{"code": -32000, "message": "execution reverted"},
+			})
+			continue
+		}
+		responses = append(responses, map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": "0x" + result})
+	}
+	json.NewEncoder(w).Encode(responses)
+}
+
+// newDEXChain prices WETH at 3000 USDC, UNI at 0.002 WETH, WBTC at 13 WETH and DAI at 1.001 USDC.
+func newDEXChain() *dexChain {
+	c := &dexChain{state: make(map[string]map[string]string)}
+	c.token(testUSDC, "USDC", "USD Coin", 6)
+	c.token(testWETH, "WETH", "Wrapped Ether", 18)
+	c.token(testUNI, "UNI", "Uniswap", 18)
+	c.token(testWBTC, "WBTC", "Wrapped BTC", 8)
+	c.token(testDAI, "DAI", "Dai Stablecoin", 18)
+	// Older tokens return bytes32 instead of a string
+	c.state[strings.ToLower(testDAI)][selSymbol] = hex.EncodeToString(append([]byte("DAI"), make([]byte, 29)...))
+
+	c.pair(testUSDCWETH, testUSDC, testWETH, units("30000000000000"), units("10000000000000000000000"))
+	c.pair(testUNIWETH, testUNI, testWETH, units("1000000000000000000000000"), units("2000000000000000000000"))
+	c.pair(testWBTCWETH, testWBTC, testWETH, units("10000000000"), units("1300000000000000000000"))
+	c.pair(testDAIUSDC, testDAI, testUSDC, units("1000000000000000000000000"), units("1001000000000"))
+	return c
+}
+
+func testDEXConfig(url string) DEXConfig {
+	return DEXConfig{
+		RPC:       url,
+		Reference: DEXPair{Token: testWETH, Pair: testUSDCWETH},
+		Pairs: []DEXPair{
+			{Token: testUNI, Pair: testUNIWETH},
+			{Token: testWBTC, Pair: testWBTCWETH},
+			{Token: testDAI, Pair: testDAIUSDC},
+		},
+	}
+}
+
+func TestDEXQuotes(t *testing.T) {
+	chain := newDEXChain()
+	srv := httptest.NewServer(chain)
+	defer srv.Close()
+
+	feed, err := NewDEX(context.Background(), testDEXConfig(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tickers []string
+	for _, s := range feed.GetSymbols() {
+		tickers = append(tickers, s.Symbol)
+	}
+	if got := strings.Join(tickers, ","); got != "WETH,UNI,WBTC,DAI" {
+		t.Fatalf("unexpected symbols %s", got)
+	}
+	if uni, _ := feed.FindSymbol("UNI"); uni.Name != "Uniswap" || uni.Id != idFromString(strings.ToLower(testUNI)) {
+		t.Fatalf("unexpected UNI symbol %+v", uni)
+	}
+
+	symbols := feed.GetSymbols()
+	chain.Lock()
+	chain.batches = nil
+	chain.Unlock()
+	quotes, err := feed.GetQuotes(context.Background(), "USD", symbols...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain.batches) != 1 || chain.batches[0] != len(symbols)+1 {
+		t.Fatalf("expected reserves of the reference and every pair in one batch, got %v", chain.batches)
+	}
+
+	want := map[string]float64{
+		// 30e6 USDC (6 decimals) against 10000 WETH (18 decimals)
+		"WETH": 3000,
+		// Priced in WETH, converted through the reference pair
+		"UNI":  0.002 * 3000,
+		"WBTC": 13 * 3000,
+		// Traded directly against the fiat token
+		"DAI": 1.001,
+	}
+	if len(quotes) != len(want) {
+		t.Fatalf("expected %d quotes, got %d", len(want), len(quotes))
+	}
+	for _, q := range quotes {
+		if math.Abs(q.Price-want[q.Symbol.Symbol]) > want[q.Symbol.Symbol]*1e-9 {
+			t.Errorf("%s price %v, want %v", q.Symbol.Symbol, q.Price, want[q.Symbol.Symbol])
+		}
+		if q.Symbol.Provider != dexName || q.LastUpdated.IsZero() {
+			t.Errorf("unexpected quote %+v", q)
+		}
+	}
+
+	if _, err := feed.GetQuotes(context.Background(), "EUR", symbols...); err == nil {
+		t.Fatal("expected an error for another currency")
+	}
+}
+
+func TestDEXOptionalNames(t *testing.T) {
+	// WBTC does not implement the optional symbol and name calls
+	chain := newDEXChain()
+	delete(chain.state[strings.ToLower(testWBTC)], selSymbol)
+	delete(chain.state[strings.ToLower(testWBTC)], selName)
+	srv := httptest.NewServer(chain)
+	defer srv.Close()
+
+	feed, err := NewDEX(context.Background(), testDEXConfig(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tickers []string
+	for _, s := range feed.GetSymbols() {
+		tickers = append(tickers, s.Symbol)
+	}
+	if got, want := strings.Join(tickers, ","), "WETH,UNI,"+strings.ToLower(testWBTC)+",DAI"; got != want {
+		t.Fatalf("got symbols %s, want %s", got, want)
+	}
+	if uni, _ := feed.FindSymbol("UNI"); uni.Name != "Uniswap" {
+		t.Fatalf("unexpected UNI symbol %+v", uni)
+	}
+}
+
+func TestDEXPrice(t *testing.T) {
+	reserves := func(r0, r1 string) []byte {
+		b, _ := hex.DecodeString(abiEncodeUint(units(r0)) + abiEncodeUint(units(r1)) + abiEncodeUint(big.NewInt(0)))
+		return b
+	}
+
+	for _, tc := range []struct {
+		name     string
+		pair     dexPair
+		reserves []byte
+		want     float64
+		err      bool
+	}{
+		{"same decimals", dexPair{tokenFirst: true, decimals: 18, quoteDec: 18}, reserves("4000", "1000"), 0.25, false},
+		{"token second", dexPair{decimals: 18, quoteDec: 18}, reserves("4000", "1000"), 4, false},
+		{"more token decimals", dexPair{decimals: 18, quoteDec: 6}, reserves("3000000000", "1000000000000000000"), 3000, false},
+		{"fewer token decimals", dexPair{tokenFirst: true, decimals: 8, quoteDec: 18}, reserves("100000000", "13000000000000000000"), 13, false},
+		{"no liquidity", dexPair{decimals: 18, quoteDec: 18}, reserves("0", "1000"), 0, true},
+		{"short data", dexPair{decimals: 18, quoteDec: 18}, make([]byte, 40), 0, true},
+	} {
+		got, err := tc.pair.price(tc.reserves)
+		if (err != nil) != tc.err {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+			continue
+		}
+		if math.Abs(got-tc.want) > tc.want*1e-12 {
+			t.Errorf("%s: price %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestDEXErrors(t *testing.T) {
+	chain := newDEXChain()
+	srv := httptest.NewServer(chain)
+	defer srv.Close()
+
+	config := testDEXConfig(srv.URL)
+	config.Pairs = append(config.Pairs, DEXPair{Token: testUSDC, Pair: testUNIWETH})
+	if _, err := NewDEX(context.Background(), config); err == nil || !strings.Contains(err.Error(), "does not trade") {
+		t.Fatalf("expected an error for a pair that does not trade the token, got %v", err)
+	}
+
+	config = testDEXConfig(srv.URL)
+	config.Pairs = append(config.Pairs, DEXPair{Token: testUNI, Pair: testUNI})
+	if _, err := NewDEX(context.Background(), config); err == nil {
+		t.Fatal("expected an error for a token address used as a pair")
+	}
+
+	if _, err := ethCalls(context.Background(), srv.URL, []ethCall{{To: testUSDC, Data: selGetReserves}}); err == nil || !strings.Contains(err.Error(), "execution reverted") {
+		t.Fatalf("expected a reverted call to fail the batch, got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package crypto
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnauthorized   = errors.New("invalid or missing API key")
+	ErrRateLimited    = errors.New("rate limited")
+	ErrUnknownSymbol  = errors.New("unknown symbol")
+	ErrPlanRestricted = errors.New("not available with the current API plan")
+	ErrInterval       = errors.New("unsupported interval")
+	ErrUnsupported    = errors.New("not supported by the provider")
+	ErrNoConsensus    = errors.New("providers disagree on the price")
+
+	// errTemporary marks failures that are likely to go away when retried.
+	errTemporary = errors.New("temporary failure")
+)
+
+// RateLimitError is returned when a provider rejects calls because of rate limits.
+// RetryAfter is zero if the provider did not tell when to retry.
+type RateLimitError struct {
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s, retry after %s: %v", ErrRateLimited, e.RetryAfter.Round(time.Second), e.Err)
+	}
+	return fmt.Sprintf("%s: %v", ErrRateLimited, e.Err)
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// RetryAfter returns how long to wait before retrying if err is a rate limit.
+func RetryAfter(err error) (time.Duration, bool) {
+	var rl *RateLimitError
+	if errors.As(err, &rl) {
+		return rl.RetryAfter, true
+	}
+	return 0, false
+}
+
+// providerErrors combines errors of several providers. errors.Is and errors.As match any of them.
+type providerErrors []error
+
+func (e providerErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e providerErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e providerErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// unknownSymbols reports symbols that a provider does not list.
+func unknownSymbols(symbol []Symbol) error {
+	tickers := make([]string, len(symbol))
+	for i, s := range symbol {
+		tickers[i] = s.Symbol
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownSymbol, strings.Join(tickers, ","))
+}
+
+// parseRetryAfter parses Retry-After header given either in seconds or as a date.
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// httpError classifies a failed HTTP response. body is a prefix of the response body.
+func httpError(url string, response *http.Response, body []byte) error {
+	err := fmt.Errorf("%s: %s", url, response.Status)
+	if msg := strings.TrimSpace(string(body)); msg != "" {
+		err = fmt.Errorf("%v: %s", err, msg)
+	}
+	switch {
+	case response.StatusCode == http.StatusUnauthorized, response.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	case response.StatusCode == http.StatusTooManyRequests:
+		return &RateLimitError{
+			RetryAfter: parseRetryAfter(response.Header.Get("Retry-After")),
+			Err:        err,
+		}
+	case response.StatusCode >= http.StatusInternalServerError:
+		return fmt.Errorf("%w: %v", errTemporary, err)
+	}
+	return err
+}
+
+var cmcErrorCode = regexp.MustCompile(`^\[(\d+)\]`)
+
+// cmcError classifies CoinMarketCap API errors that are reported as "[code] message".
+func cmcError(err error) error {
+	if err == nil {
+		return nil
+	}
+	m := cmcErrorCode.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+	code, _ := strconv.Atoi(m[1])
+	now := time.Now().UTC()
+	switch code {
+	case 1001, 1002:
+		return fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	case 1003, 1004, 1006:
+		return fmt.Errorf("%w: %v", ErrPlanRestricted, err)
+	case 1008, 1011:
+		return &RateLimitError{RetryAfter: time.Minute, Err: err}
+	case 1009:
+		return &RateLimitError{RetryAfter: now.Truncate(24 * time.Hour).Add(24 * time.Hour).Sub(now), Err: err}
+	case 1010:
+		return &RateLimitError{RetryAfter: time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC).Sub(now), Err: err}
+	}
+	if code == 400 && strings.Contains(err.Error(), "Invalid value") {
+		return fmt.Errorf("%w: %v", ErrUnknownSymbol, err)
+	}
+	return err
+}
@@ -0,0 +1,50 @@
+package crypto
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCMCError(t *testing.T) {
+	for _, tc := range []struct {
+		msg  string
+		want error
+	}{
+		{"[1001] This API Key is invalid.", ErrUnauthorized},
+		{"[1002] API key missing.", ErrUnauthorized},
+		{"[1006] Your API Key subscription plan doesn't support this endpoint.", ErrPlanRestricted},
+		{"[1008] You've exceeded your API Key's HTTP request rate limit.", ErrRateLimited},
+		{"[1011] You've hit an IP rate limit.", ErrRateLimited},
+		{"[1009] You've exceeded your API Key's daily rate limit.", ErrRateLimited},
+		{"[1010] You've exceeded your API Key's monthly rate limit.", ErrRateLimited},
+		{`[400] Invalid value for "symbol": "XYZ"`, ErrUnknownSymbol},
+	} {
+		err := cmcError(errors.New(tc.msg))
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.msg, err, tc.want)
+		}
+	}
+
+	now := time.Now().UTC()
+	for code, max := range map[string]time.Duration{
+		"[1008] minute":  time.Minute,
+		"[1009] daily":   24 * time.Hour,
+		"[1010] monthly": time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC).Sub(now),
+	} {
+		after, ok := RetryAfter(cmcError(errors.New(code)))
+		if !ok || after <= 0 || after > max {
+			t.Errorf("%s: retry after %v, want up to %v", code, after, max)
+		}
+	}
+
+	for _, msg := range []string{"[500] Internal error", "[400] Bad request", "connection reset"} {
+		err := errors.New(msg)
+		if got := cmcError(err); got != err {
+			t.Errorf("%s: unexpected classification %v", msg, got)
+		}
+	}
+	if cmcError(nil) != nil {
+		t.Error("nil error is classified")
+	}
+}
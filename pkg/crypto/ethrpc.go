@@ -0,0 +1,220 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Function selectors of the contract calls used by the DEX feed.
+const (
+	selToken0      = "0x0dfe1681"
+	selToken1      = "0xd21220a7"
+	selGetReserves = "0x0902f1ac"
+	selDecimals    = "0x313ce567"
+	selSymbol      = "0x95d89b41"
+	selName        = "0x06fdde03"
+)
+
+var ethAddress = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+type ethCall struct {
+	To   string `json:"to"`
+	Data string `json:"data"`
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("[%d] %s", e.Code, e.Message)
+}
+
+// rpcErr classifies JSON-RPC errors. Nodes report rate limits as -32005.
+func rpcErr(url string, e *rpcError) error {
+	err := fmt.Errorf("%s: %w", url, e)
+	if e.Code == -32005 || e.Code == 429 {
+		return &RateLimitError{Err: err}
+	}
+	return err
+}
+
+// ethCalls runs calls against the latest block in a single JSON-RPC batch and returns their results in order.
+// A failed call fails the whole batch.
+func ethCalls(ctx context.Context, url string, calls []ethCall) ([][]byte, error) {
+	results, errs, err := ethBatch(ctx, url, calls)
+	if err != nil {
+		return nil, err
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// ethBatch runs calls against the latest block in a single JSON-RPC batch and returns their results in order
+// along with the error of each call that failed.
+func ethBatch(ctx context.Context, url string, calls []ethCall) ([][]byte, []error, error) {
+	if len(calls) == 0 {
+		return nil, nil, nil
+	}
+
+	batch := make([]rpcRequest, len(calls))
+	for i, c := range calls {
+		batch[i] = rpcRequest{
+			JSONRPC: "2.0",
+			ID:      i,
+			Method:  "eth_call",
+			Params:  []interface{}{c, "latest"},
+		}
+	}
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var raw json.RawMessage
+	err = DefaultRetry.Do(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		response, err := httpClient().Do(req)
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusOK {
+			msg, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+			return httpError(url, response, msg)
+		}
+		raw = nil
+		if err := json.NewDecoder(response.Body).Decode(&raw); err != nil {
+			return err
+		}
+
+		// Nodes answer a rejected batch with a single error
+		if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '{' {
+			var single rpcResponse
+			if err := json.Unmarshal(trimmed, &single); err != nil {
+				return err
+			}
+			if single.Error != nil {
+				return rpcErr(url, single.Error)
+			}
+			return fmt.Errorf("%s: unexpected response to a batch", url)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var responses []rpcResponse
+	if err := json.Unmarshal(raw, &responses); err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", url, err)
+	}
+	ret := make([][]byte, len(calls))
+	errs := make([]error, len(calls))
+	seen := make([]bool, len(calls))
+	for _, r := range responses {
+		if r.ID < 0 || r.ID >= len(calls) {
+			continue
+		}
+		seen[r.ID] = true
+		if r.Error != nil {
+			errs[r.ID] = fmt.Errorf("%s(%s): %w", calls[r.ID].To, calls[r.ID].Data, rpcErr(url, r.Error))
+			continue
+		}
+		var result string
+		if err := json.Unmarshal(r.Result, &result); err != nil {
+			errs[r.ID] = fmt.Errorf("%s: %v", url, err)
+			continue
+		}
+		data, err := hex.DecodeString(strings.TrimPrefix(result, "0x"))
+		if err != nil {
+			errs[r.ID] = fmt.Errorf("%s: %v", url, err)
+			continue
+		}
+		ret[r.ID] = data
+	}
+	for i, ok := range seen {
+		if !ok {
+			errs[i] = fmt.Errorf("%s: no result for %s(%s)", url, calls[i].To, calls[i].Data)
+		}
+	}
+	return ret, errs, nil
+}
+
+// abiWord returns the i-th 32 byte word of ABI encoded data.
+func abiWord(data []byte, i int) ([]byte, error) {
+	if len(data) < (i+1)*32 {
+		return nil, fmt.Errorf("short ABI data: %d bytes", len(data))
+	}
+	return data[i*32 : (i+1)*32], nil
+}
+
+func abiUint(data []byte, i int) (*big.Int, error) {
+	w, err := abiWord(data, i)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(w), nil
+}
+
+func abiAddress(data []byte, i int) (string, error) {
+	w, err := abiWord(data, i)
+	if err != nil {
+		return "", err
+	}
+	return "0x" + hex.EncodeToString(w[12:]), nil
+}
+
+// abiString decodes a string return value. Some older tokens return bytes32 instead.
+func abiString(data []byte) (string, error) {
+	if len(data) == 32 {
+		return string(bytes.TrimRight(data, "\x00")), nil
+	}
+	offset, err := abiUint(data, 0)
+	if err != nil {
+		return "", err
+	}
+	if !offset.IsInt64() || offset.Int64()%32 != 0 || offset.Int64() >= int64(len(data)) {
+		return "", fmt.Errorf("bad ABI string offset %v", offset)
+	}
+	start := int(offset.Int64())
+	length, err := abiUint(data[start:], 0)
+	if err != nil {
+		return "", err
+	}
+	if !length.IsInt64() || int64(start+32)+length.Int64() > int64(len(data)) {
+		return "", fmt.Errorf("bad ABI string length %v", length)
+	}
+	return string(data[start+32 : start+32+int(length.Int64())]), nil
+}
@@ -0,0 +1,167 @@
+package crypto
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/itohio/CoinWatcher/pkg/logger"
+)
+
+// FailoverHandler is notified whenever failover switches to another provider.
+type FailoverHandler func(from, to Crypto, err error)
+
+type failover struct {
+	sync.Mutex
+	providers   []Crypto
+	maxFailures int
+	cooldown    time.Duration
+	onSwitch    FailoverHandler
+
+	current  int
+	failures int
+	switched time.Time
+}
+
+var (
+	_ Crypto    = &failover{}
+	_ Describer = &failover{}
+)
+
+// NewFailover calls providers in order. The current provider is abandoned after maxFailures
+// consecutive failures and the primary one is retried after cooldown.
+func NewFailover(maxFailures int, cooldown time.Duration, onSwitch FailoverHandler, providers ...Crypto) *failover {
+	if maxFailures < 1 {
+		maxFailures = 1
+	}
+	return &failover{
+		providers:   providers,
+		maxFailures: maxFailures,
+		cooldown:    cooldown,
+		onSwitch:    onSwitch,
+	}
+}
+
+// Current returns the provider that is currently being used. Only calls switch providers.
+func (c *failover) Current() Crypto {
+	c.Lock()
+	defer c.Unlock()
+	return c.providers[c.current]
+}
+
+func (c *failover) switchTo(idx int, err error) {
+	from := c.providers[c.current]
+	c.current = idx
+	c.failures = 0
+	c.switched = time.Now()
+	to := c.providers[c.current]
+
+	logger.Log.Warn().Err(err).Str("from", from.Name()).Str("to", to.Name()).Msg("Failover")
+	if c.onSwitch != nil {
+		go c.onSwitch(from, to, err)
+	}
+}
+
+// call runs f starting with the current provider and moving on to the next ones until one succeeds or ctx is done.
+func (c *failover) call(ctx context.Context, f func(p Crypto) error) error {
+	if len(c.providers) == 0 {
+		return errors.New("no providers")
+	}
+
+	c.Lock()
+	// The primary provider is retried after cooldown
+	if c.current != 0 && time.Since(c.switched) >= c.cooldown {
+		c.switchTo(0, nil)
+	}
+	start := c.current
+	c.Unlock()
+
+	var err error
+	for i := range c.providers {
+		idx := (start + i) % len(c.providers)
+		if err = f(c.providers[idx]); err == nil {
+			c.Lock()
+			if idx == c.current {
+				c.failures = 0
+			}
+			c.Unlock()
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		if errors.Is(err, ErrUnknownSymbol) {
+			continue
+		}
+
+		c.Lock()
+		if idx == c.current {
+			c.failures++
+			if c.failures >= c.maxFailures {
+				c.switchTo((idx+1)%len(c.providers), err)
+			}
+		}
+		c.Unlock()
+	}
+
+	return err
+}
+
+func (c *failover) Name() string {
+	if len(c.providers) == 0 {
+		return "Failover"
+	}
+	return c.Current().Name()
+}
+
+func (c *failover) GetSymbols() []Symbol {
+	if len(c.providers) == 0 {
+		return nil
+	}
+	return c.Current().GetSymbols()
+}
+
+func (c *failover) GetCurrencies() []string {
+	if len(c.providers) == 0 {
+		return nil
+	}
+	return c.Current().GetCurrencies()
+}
+
+func (c *failover) FindSymbol(symbol string) (Symbol, bool) {
+	if len(c.providers) == 0 {
+		return Symbol{}, false
+	}
+	if s, ok := c.Current().FindSymbol(symbol); ok {
+		return s, true
+	}
+	for _, p := range c.providers {
+		if s, ok := p.FindSymbol(symbol); ok {
+			return s, true
+		}
+	}
+	return Symbol{}, false
+}
+
+func (c *failover) GetQuotes(ctx context.Context, currency string, symbol ...Symbol) (ret []Quote, err error) {
+	err = c.call(ctx, func(p Crypto) error {
+		var err error
+		ret, err = p.GetQuotes(ctx, currency, symbol...)
+		return err
+	})
+	return
+}
+
+func (c *failover) GetOHLCV(ctx context.Context, currency string, interval time.Duration, start, end time.Time, symbol ...Symbol) (ret []Ohlcv, err error) {
+	err = c.call(ctx, func(p Crypto) error {
+		var err error
+		ret, err = p.GetOHLCV(ctx, currency, interval, start, end, symbol...)
+		return err
+	})
+	return
+}
+
+func (c *failover) GetInfo(ctx context.Context, symbol Symbol) (Info, error) {
+	return describe(ctx, symbol, c.providers...)
+}
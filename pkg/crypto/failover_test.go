@@ -0,0 +1,61 @@
+package crypto
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFailover(t *testing.T) {
+	primary, backup := newUSDFeed(), newUSDFeed()
+	primary.name, backup.name = "primary", "backup"
+	primary.err = ErrRateLimited
+
+	switches := make(chan string, 10)
+	c := NewFailover(2, time.Hour, func(from, to Crypto, err error) {
+		switches <- from.Name() + ">" + to.Name()
+	}, primary, backup)
+	btc := Symbol{Symbol: "BTC"}
+
+	// Failed calls fall back to the next provider before switching to it
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetQuotes(context.Background(), "USD", btc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := <-switches; got != "primary>backup" || c.Name() != "backup" {
+		t.Fatalf("unexpected switch %s to %s", got, c.Name())
+	}
+
+	// Reading the current provider never switches back, even after cooldown
+	primary.err = nil
+	c.switched = time.Now().Add(-time.Hour * 2)
+	if c.Name() != "backup" || len(c.GetSymbols()) != 1 || len(c.GetCurrencies()) != 2 {
+		t.Fatal("unexpected current provider")
+	}
+	select {
+	case got := <-switches:
+		t.Fatalf("reading the provider switched %s", got)
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	if _, err := c.GetQuotes(context.Background(), "USD", btc); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-switches; got != "backup>primary" || c.Name() != "primary" {
+		t.Fatalf("unexpected switch %s to %s", got, c.Name())
+	}
+}
+
+func TestFailoverErrors(t *testing.T) {
+	a, b := newUSDFeed(), newUSDFeed()
+	a.err, b.err = ErrUnauthorized, ErrRateLimited
+	c := NewFailover(1, time.Hour, nil, a, b)
+	if _, err := c.GetQuotes(context.Background(), "USD", Symbol{Symbol: "BTC"}); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected the last provider error, got %v", err)
+	}
+	if _, err := NewFailover(1, time.Hour, nil).GetQuotes(context.Background(), "USD"); err == nil {
+		t.Fatal("expected an error without providers")
+	}
+}
@@ -0,0 +1,103 @@
+package crypto
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const ECBURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+// RateSource provides fiat exchange rates.
+type RateSource interface {
+	Name() string
+	// Rates returns exchange rates of currencies against a common base currency, which has the rate of 1.
+	Rates(ctx context.Context) (map[string]float64, error)
+}
+
+type ecb struct {
+	url string
+}
+
+var _ RateSource = &ecb{}
+
+type ecbEnvelope struct {
+	Cube struct {
+		Cube struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string  `xml:"currency,attr"`
+				Rate     float64 `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// NewECB reads European Central Bank reference rates from url. Url may also be a path to a local copy
+// of the daily XML file. Empty url uses ECBURL.
+func NewECB(url string) *ecb {
+	if url == "" {
+		url = ECBURL
+	}
+	return &ecb{
+		url: url,
+	}
+}
+
+func (c *ecb) Name() string {
+	return "ECB"
+}
+
+func (c *ecb) open(ctx context.Context) (io.ReadCloser, error) {
+	if !strings.HasPrefix(c.url, "http://") && !strings.HasPrefix(c.url, "https://") {
+		return os.Open(strings.TrimPrefix(c.url, "file://"))
+	}
+
+	var body io.ReadCloser
+	err := DefaultRetry.Do(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+		if err != nil {
+			return err
+		}
+		response, err := httpClient().Do(req)
+		if err != nil {
+			return err
+		}
+		if response.StatusCode != http.StatusOK {
+			defer response.Body.Close()
+			msg, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+			return httpError(c.url, response, msg)
+		}
+		body = response.Body
+		return nil
+	})
+	return body, err
+}
+
+func (c *ecb) Rates(ctx context.Context) (map[string]float64, error) {
+	reader, err := c.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(reader).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("%s: %v", c.url, err)
+	}
+
+	rates := map[string]float64{"EUR": 1}
+	for _, r := range envelope.Cube.Cube.Rates {
+		if r.Rate > 0 {
+			rates[strings.ToUpper(r.Currency)] = r.Rate
+		}
+	}
+	if len(rates) == 1 {
+		return nil, fmt.Errorf("%s: no rates", c.url)
+	}
+	return rates, nil
+}
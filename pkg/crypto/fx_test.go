@@ -0,0 +1,58 @@
+package crypto
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+var ecbFixture = filepath.Join("testdata", "ecb", "eurofxref-daily.xml")
+
+func ecbServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/eurofxref-daily.xml":
+			w.Header().Set("Content-Type", "text/xml")
+			http.ServeFile(w, r, ecbFixture)
+		case "/empty.xml":
+			w.Write([]byte(`<Envelope><Cube><Cube time="2024-01-02"></Cube></Cube></Envelope>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestECBRates(t *testing.T) {
+	srv := ecbServer(t)
+	abs, err := filepath.Abs(ecbFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]float64{"EUR": 1, "USD": 1.095, "JPY": 155.48, "GBP": 0.861, "CHF": 0.93, "PLN": 4.3395}
+	for _, url := range []string{ecbFixture, "file://" + abs, srv.URL + "/eurofxref-daily.xml"} {
+		rates, err := NewECB(url).Rates(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %v", url, err)
+		}
+		if len(rates) != len(want) {
+			t.Fatalf("%s: got %d rates, want %d", url, len(rates), len(want))
+		}
+		for currency, rate := range want {
+			if rates[currency] != rate {
+				t.Errorf("%s: %s rate %v, want %v", url, currency, rates[currency], rate)
+			}
+		}
+	}
+
+	for _, url := range []string{srv.URL + "/empty.xml", srv.URL + "/missing.xml", filepath.Join("testdata", "ecb", "missing.xml")} {
+		if _, err := NewECB(url).Rates(context.Background()); err == nil {
+			t.Errorf("%s: expected an error", url)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package crypto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/itohio/CoinWatcher/pkg/logger"
+)
+
+// RetryPolicy retries temporary failures and rate limits with exponential backoff and jitter.
+type RetryPolicy struct {
+	// Attempts is the maximum number of calls
+	Attempts int
+	// Min is the delay before the first retry
+	Min time.Duration
+	// Max caps the delay. Rate limits lifted later than that are not waited for.
+	Max time.Duration
+}
+
+// DefaultRetry is the retry policy used by all providers.
+var DefaultRetry = RetryPolicy{
+	Attempts: 3,
+	Min:      time.Second,
+	Max:      time.Second * 30,
+}
+
+// Delay returns the randomized delay before retry attempt, starting at zero.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	d := p.Min << uint(attempt)
+	if d > p.Max || d <= 0 {
+		d = p.Max
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// Retryable reports whether err is likely to go away when the call is retried: rate limits, server errors,
+// timeouts and connections that were reset or refused.
+func Retryable(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, errTemporary) ||
+		errors.As(err, &netErr) && netErr.Timeout() ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// Do calls f until it succeeds, fails with an error that is not Retryable, runs out of attempts or ctx is done.
+func (p RetryPolicy) Do(ctx context.Context, f func() error) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = f(); err == nil || !Retryable(err) || attempt+1 >= p.Attempts || ctx.Err() != nil {
+			return err
+		}
+
+		delay := p.Delay(attempt)
+		if after, ok := RetryAfter(err); ok && after > 0 {
+			if after > p.Max {
+				return err
+			}
+			delay = after
+		}
+		logger.Log.Warn().Err(err).Dur("delay", delay).Int("attempt", attempt+1).Msg("Retrying")
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// responseClassifier classifies failed responses of a provider. It returns nil for responses
+// that httpError classifies.
+type responseClassifier func(url string, response *http.Response, body []byte) error
+
+// getJSON decodes the response to a GET request of url into v. Failed responses are classified
+// by classify if it is not nil, then by httpError.
+func getJSON(ctx context.Context, url string, v interface{}, classify responseClassifier) error {
+	return DefaultRetry.Do(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		response, err := httpClient().Do(req)
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+			if classify != nil {
+				if err := classify(url, response, body); err != nil {
+					return err
+				}
+			}
+			return httpError(url, response, body)
+		}
+
+		return json.NewDecoder(response.Body).Decode(v)
+	})
+}
+
+// idFromString derives a stable numeric Symbol.Id for providers that identify coins by string.
+func idFromString(id string) int {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return int(h.Sum32() & 0x7fffffff)
+}
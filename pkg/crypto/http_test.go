@@ -0,0 +1,192 @@
+package crypto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// timeoutError is a net.Error that may or may not be a timeout.
+type timeoutError bool
+
+func (e timeoutError) Error() string   { return "i/o" }
+func (e timeoutError) Timeout() bool   { return bool(e) }
+func (e timeoutError) Temporary() bool { return true }
+
+func TestRetryable(t *testing.T) {
+	opError := func(err error) error {
+		return &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", err)}
+	}
+	for _, tc := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"rate limit", &RateLimitError{Err: errors.New("429")}, true},
+		{"server error", fmt.Errorf("%w: 502 Bad Gateway", errTemporary), true},
+		{"timeout", timeoutError(true), true},
+		{"reset", opError(syscall.ECONNRESET), true},
+		{"refused", fmt.Errorf("get: %w", opError(syscall.ECONNREFUSED)), true},
+		{"other network error", timeoutError(false), false},
+		{"no such host", &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}, false},
+		{"unauthorized", fmt.Errorf("%w: 401", ErrUnauthorized), false},
+		{"unknown symbol", ErrUnknownSymbol, false},
+		{"nil", nil, false},
+	} {
+		if got := Retryable(tc.err); got != tc.want {
+			t.Errorf("%s: Retryable(%v) = %v, want %v", tc.name, tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	p := RetryPolicy{Attempts: 5, Min: time.Second, Max: time.Second * 5}
+	for attempt, max := range []time.Duration{time.Second, time.Second * 2, time.Second * 4, time.Second * 5, time.Second * 5} {
+		for i := 0; i < 20; i++ {
+			if d := p.Delay(attempt); d < max/2 || d > max {
+				t.Fatalf("attempt %d: delay %v out of [%v, %v]", attempt, d, max/2, max)
+			}
+		}
+	}
+	if d := p.Delay(100); d < p.Max/2 || d > p.Max {
+		t.Fatalf("overflowing delay is not capped: %v", d)
+	}
+}
+
+func TestRetryDo(t *testing.T) {
+	p := RetryPolicy{Attempts: 3, Min: time.Millisecond, Max: time.Millisecond * 10}
+	for _, tc := range []struct {
+		name  string
+		err   error
+		calls int
+	}{
+		{"success", nil, 1},
+		{"retryable", errTemporary, 3},
+		{"permanent", ErrUnauthorized, 1},
+		{"rate limit lifted soon", &RateLimitError{RetryAfter: time.Millisecond, Err: errors.New("429")}, 3},
+		{"rate limit lifted later than max", &RateLimitError{RetryAfter: time.Hour, Err: errors.New("429")}, 1},
+	} {
+		calls := 0
+		err := p.Do(context.Background(), func() error {
+			calls++
+			return tc.err
+		})
+		if calls != tc.calls || !errors.Is(err, tc.err) {
+			t.Errorf("%s: %d calls, error %v", tc.name, calls, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	p.Do(ctx, func() error {
+		calls++
+		cancel()
+		return errTemporary
+	})
+	if calls != 1 {
+		t.Fatalf("retried after the context was done: %d calls", calls)
+	}
+
+	calls = 0
+	p.Do(context.Background(), func() error {
+		calls++
+		if calls == 1 {
+			return errTemporary
+		}
+		return nil
+	})
+	if calls != 2 {
+		t.Fatalf("retried after success: %d calls", calls)
+	}
+}
+
+func TestHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/limited":
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		case "/unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	get := func(path string) error {
+		response, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		body, _ := io.ReadAll(response.Body)
+		return httpError(srv.URL+path, response, body)
+	}
+	if err := get("/limited"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("429: %v", err)
+	} else if after, _ := RetryAfter(err); after != time.Second*7 {
+		t.Errorf("429: retry after %v", after)
+	}
+	for path, want := range map[string]error{"/forbidden": ErrUnauthorized, "/unavailable": errTemporary} {
+		if err := get(path); !errors.Is(err, want) {
+			t.Errorf("%s: got %v, want %v", path, err, want)
+		}
+	}
+	if err := get("/missing"); err == nil || Retryable(err) {
+		t.Errorf("404: %v", err)
+	}
+}
+
+func TestGetJSONCanceled(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, tc := range []struct {
+		name     string
+		timeout  time.Duration
+		ctx      context.Context
+		want     error
+		requests int32
+	}{
+		{"canceled", 0, canceled, context.Canceled, 0},
+		{"deadline", time.Millisecond * 50, context.Background(), context.DeadlineExceeded, 1},
+	} {
+		atomic.StoreInt32(&requests, 0)
+		ctx := tc.ctx
+		if tc.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+			defer cancel()
+		}
+		start := time.Now()
+		var v interface{}
+		err := getJSON(ctx, srv.URL, &v, nil)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+		if d := time.Since(start); d > time.Second {
+			t.Errorf("%s: returned after %v", tc.name, d)
+		}
+		if n := atomic.LoadInt32(&requests); n > tc.requests {
+			t.Errorf("%s: sent %d requests, want at most %d", tc.name, n, tc.requests)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package crypto
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/itohio/CoinWatcher/pkg/logger"
+)
+
+const (
+	callSymbols    = "GetSymbols"
+	callCurrencies = "GetCurrencies"
+	callQuotes     = "GetQuotes"
+	callOHLCV      = "GetOHLCV"
+)
+
+// fixture is a single line of a recorded fixture file.
+type fixture struct {
+	Time     time.Time       `json:"time"`
+	Call     string          `json:"call"`
+	Currency string          `json:"currency,omitempty"`
+	Interval time.Duration   `json:"interval,omitempty"`
+	Start    time.Time       `json:"start"`
+	End      time.Time       `json:"end"`
+	Symbols  []Symbol        `json:"symbols,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Result   json.RawMessage `json:"result,omitempty"`
+}
+
+type recorder struct {
+	sync.Mutex
+	Crypto
+	file   *os.File
+	enc    *json.Encoder
+	closed bool
+	loaded chan struct{}
+}
+
+type streamRecorder struct {
+	*recorder
+	streamer Streamer
+}
+
+var (
+	_ Crypto    = &recorder{}
+	_ Describer = &recorder{}
+	_ Loader    = &recorder{}
+	_ Streamer  = &streamRecorder{}
+)
+
+// NewRecorder wraps feed and appends every result it returns to the fixture file at path.
+// Symbol and currency lists are static for a feed, so they are written once the feed has loaded them.
+// The result is a Streamer if feed is, streamed quotes are recorded as single quote results.
+// It is an io.Closer that closes the file.
+func NewRecorder(feed Crypto, path string) (Crypto, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &recorder{
+		Crypto: feed,
+		file:   file,
+		enc:    json.NewEncoder(file),
+		loaded: make(chan struct{}),
+	}
+	if loader, ok := feed.(Loader); ok {
+		go func() {
+			defer close(ret.loaded)
+			<-loader.Loaded()
+			if err := loader.Err(); err != nil {
+				logger.Log.Error().Err(err).Str("file", path).Msg("Recording without symbol lists")
+				return
+			}
+			ret.recordLists()
+		}()
+	} else {
+		ret.recordLists()
+		close(ret.loaded)
+	}
+
+	if streamer, ok := feed.(Streamer); ok {
+		return &streamRecorder{
+			recorder: ret,
+			streamer: streamer,
+		}, nil
+	}
+	return ret, nil
+}
+
+func (c *recorder) recordLists() {
+	c.record(fixture{Call: callSymbols}, c.Crypto.GetSymbols(), nil)
+	c.record(fixture{Call: callCurrencies}, c.Crypto.GetCurrencies(), nil)
+}
+
+func (c *recorder) record(f fixture, result interface{}, err error) {
+	f.Time = time.Now()
+	if err != nil {
+		f.Error = err.Error()
+	} else if data, err := json.Marshal(result); err == nil {
+		f.Result = data
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	// Calls in flight may still finish after the recording is closed
+	if c.closed {
+		return
+	}
+	if err := c.enc.Encode(&f); err != nil {
+		logger.Log.Error().Err(err).Str("file", c.file.Name()).Msg("Could not record")
+	}
+}
+
+// GetInfo is passed through without recording, replays have no metadata.
+func (c *recorder) GetInfo(ctx context.Context, symbol Symbol) (Info, error) {
+	return describe(ctx, symbol, c.Crypto)
+}
+
+// Loaded is closed once the wrapped feed has loaded and its lists are recorded.
+// Feeds that are not Loaders are loaded already.
+func (c *recorder) Loaded() <-chan struct{} {
+	return c.loaded
+}
+
+func (c *recorder) Err() error {
+	if loader, ok := c.Crypto.(Loader); ok {
+		return loader.Err()
+	}
+	return nil
+}
+
+// Close closes the fixture file. Results returned afterwards are not recorded.
+func (c *recorder) Close() error {
+	c.Lock()
+	defer c.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.file.Close()
+}
+
+func (c *recorder) GetQuotes(ctx context.Context, currency string, symbol ...Symbol) ([]Quote, error) {
+	ret, err := c.Crypto.GetQuotes(ctx, currency, symbol...)
+	c.record(fixture{Call: callQuotes, Currency: currency, Symbols: symbol}, ret, err)
+	return ret, err
+}
+
+func (c *recorder) GetOHLCV(ctx context.Context, currency string, interval time.Duration, start, end time.Time, symbol ...Symbol) ([]Ohlcv, error) {
+	ret, err := c.Crypto.GetOHLCV(ctx, currency, interval, start, end, symbol...)
+	c.record(fixture{Call: callOHLCV, Currency: currency, Interval: interval, Start: start, End: end, Symbols: symbol}, ret, err)
+	return ret, err
+}
+
+func (c *streamRecorder) Subscribe(ctx context.Context, currency string, symbol ...Symbol) (<-chan Quote, error) {
+	quotes, err := c.streamer.Subscribe(ctx, currency, symbol...)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(chan Quote)
+	go func() {
+		defer close(ret)
+		for q := range quotes {
+			c.record(fixture{Call: callQuotes, Currency: currency, Symbols: []Symbol{q.Symbol}}, []Quote{q}, nil)
+			select {
+			case ret <- q:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ret, nil
+}
@@ -0,0 +1,189 @@
+package crypto
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// streamFeed is a testFeed that loads in the background and streams quotes pushed to stream.
+type streamFeed struct {
+	*testFeed
+	stream  chan Quote
+	loaded  chan struct{}
+	loadErr error
+}
+
+func (f *streamFeed) Subscribe(ctx context.Context, currency string, symbol ...Symbol) (<-chan Quote, error) {
+	return f.stream, nil
+}
+
+func (f *streamFeed) Loaded() <-chan struct{} {
+	return f.loaded
+}
+
+func (f *streamFeed) Err() error {
+	return f.loadErr
+}
+
+func TestRecorderForwards(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.jsonl")
+	plain, err := NewRecorder(newUSDFeed(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plain.(io.Closer).Close()
+	if _, ok := plain.(Streamer); ok {
+		t.Fatal("recorder streams a feed that does not")
+	}
+	select {
+	case <-plain.(Loader).Loaded():
+	default:
+		t.Fatal("a feed that does not load in the background is not loaded")
+	}
+
+	feed := &streamFeed{testFeed: newUSDFeed(), stream: make(chan Quote, 1), loaded: make(chan struct{}), loadErr: ErrUnauthorized}
+	rec, err := NewRecorder(feed, filepath.Join(t.TempDir(), "fixture.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rec.(io.Closer).Close()
+
+	select {
+	case <-rec.(Loader).Loaded():
+		t.Fatal("loaded before the feed")
+	default:
+	}
+	close(feed.loaded)
+	<-rec.(Loader).Loaded()
+	if !errors.Is(rec.(Loader).Err(), ErrUnauthorized) {
+		t.Fatalf("load error is lost: %v", rec.(Loader).Err())
+	}
+
+	streamer, ok := rec.(Streamer)
+	if !ok {
+		t.Fatal("recorder hides the stream")
+	}
+	quotes, err := streamer.Subscribe(context.Background(), "USD", Symbol{Symbol: "BTC"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed.stream <- Quote{Symbol: Symbol{Provider: "test", Id: 1, Symbol: "BTC"}, Price: 44000, LastUpdated: time.Now()}
+	if q := <-quotes; q.Price != 44000 {
+		t.Fatalf("unexpected streamed quote %+v", q)
+	}
+	close(feed.stream)
+	if _, ok := <-quotes; ok {
+		t.Fatal("stream is not closed with the feed's")
+	}
+}
+
+func TestRecorderReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.jsonl")
+	feed := &streamFeed{testFeed: newUSDFeed(), stream: make(chan Quote, 1), loaded: make(chan struct{})}
+	close(feed.loaded)
+	rec, err := NewRecorder(feed, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-rec.(Loader).Loaded()
+
+	btc := Symbol{Symbol: "BTC"}
+	if _, err := rec.GetQuotes(context.Background(), "USD", btc); err != nil {
+		t.Fatal(err)
+	}
+	quotes, err := rec.(Streamer).Subscribe(context.Background(), "USD", btc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed.stream <- Quote{Symbol: Symbol{Provider: "test", Id: 1, Symbol: "BTC"}, Price: 44000}
+	<-quotes
+	close(feed.stream)
+	for range quotes {
+	}
+	rec.(io.Closer).Close()
+
+	replay, err := NewReplay(path, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replay.GetSymbols()) != 1 || len(replay.GetCurrencies()) != 2 {
+		t.Fatalf("lists are not recorded: %v %v", replay.GetSymbols(), replay.GetCurrencies())
+	}
+	got, err := replay.GetQuotes(context.Background(), "USD", btc)
+	if err != nil || len(got) != 1 || got[0].Price != 44000 {
+		t.Fatalf("the latest streamed quote is not replayed: %+v %v", got, err)
+	}
+}
+
+func TestRecorderLists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.jsonl")
+	feed := &streamFeed{testFeed: newUSDFeed(), loaded: make(chan struct{})}
+	// Listings are not known until the feed has loaded
+	quotes := feed.quotes
+	feed.quotes = map[string][]Quote{"USD": nil}
+	rec, err := NewRecorder(feed, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	feed.quotes = quotes
+	close(feed.loaded)
+	<-rec.(Loader).Loaded()
+	if err := rec.(io.Closer).Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rec.GetQuotes(context.Background(), "USD", Symbol{Symbol: "BTC"}); err != nil {
+		t.Fatalf("closed recorder fails calls: %v", err)
+	}
+	if err := rec.(io.Closer).Close(); err != nil {
+		t.Fatalf("closing twice fails: %v", err)
+	}
+
+	replay, err := NewReplay(path, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := replay.GetSymbols(); len(s) != 1 || s[0].Symbol != "BTC" {
+		t.Fatalf("symbols are recorded before loading: %v", s)
+	}
+	if q, _ := replay.GetQuotes(context.Background(), "USD", Symbol{Symbol: "BTC"}); len(q) != 0 {
+		t.Fatalf("quotes are recorded after closing: %v", q)
+	}
+}
+
+func TestReplayOHLCVRange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.jsonl")
+	rec, err := NewRecorder(newUSDFeed(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	btc := Symbol{Symbol: "BTC"}
+	open := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := rec.GetOHLCV(context.Background(), "USD", time.Hour, open, open.Add(time.Hour), btc); err != nil {
+		t.Fatal(err)
+	}
+	rec.(io.Closer).Close()
+
+	replay, err := NewReplay(path, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		start, end time.Time
+		want       int
+	}{
+		{open, open.Add(time.Hour), 1},
+		{open.Add(-time.Hour), open, 1},
+		{open.Add(time.Minute), open.Add(time.Hour), 0},
+		{open.Add(-time.Hour), open.Add(-time.Minute), 0},
+	} {
+		candles, err := replay.GetOHLCV(context.Background(), "USD", time.Hour, tc.start, tc.end, btc)
+		if err != nil || len(candles) != tc.want {
+			t.Errorf("candles from %v to %v: got %d %v, want %d", tc.start, tc.end, len(candles), err, tc.want)
+		}
+	}
+}
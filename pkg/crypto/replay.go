@@ -0,0 +1,202 @@
+package crypto
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+type replay struct {
+	name       string
+	symbols    []Symbol
+	currencies []string
+	quotes     []fixture
+	ohlcv      []fixture
+	iconCache  Cache
+
+	first   time.Time
+	started time.Time
+	speed   float64
+}
+
+var _ Crypto = &replay{}
+
+// NewReplay serves results recorded by NewRecorder. The recording is replayed speed times faster
+// than real time starting from the first recorded call. Zero speed serves the latest results right away.
+func NewReplay(path string, speed float64, iconCache Cache) (*replay, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	ret := &replay{
+		name:      fmt.Sprintf("Replay(%s)", filepath.Base(path)),
+		iconCache: iconCache,
+		started:   time.Now(),
+		speed:     speed,
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var f fixture
+		if err := json.Unmarshal(scanner.Bytes(), &f); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		if ret.first.IsZero() || f.Time.Before(ret.first) {
+			ret.first = f.Time
+		}
+
+		switch f.Call {
+		case callSymbols:
+			if err := json.Unmarshal(f.Result, &ret.symbols); err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+			}
+		case callCurrencies:
+			if err := json.Unmarshal(f.Result, &ret.currencies); err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+			}
+		case callQuotes:
+			ret.quotes = append(ret.quotes, f)
+		case callOHLCV:
+			ret.ohlcv = append(ret.ohlcv, f)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range ret.symbols {
+		ret.symbols[i].iconCache = iconCache
+	}
+	sort.SliceStable(ret.quotes, func(i, j int) bool { return ret.quotes[i].Time.Before(ret.quotes[j].Time) })
+	sort.SliceStable(ret.ohlcv, func(i, j int) bool { return ret.ohlcv[i].Time.Before(ret.ohlcv[j].Time) })
+
+	return ret, nil
+}
+
+// now returns the point of the recording that is being replayed.
+func (c *replay) now() time.Time {
+	if c.speed <= 0 {
+		return time.Now().Add(time.Hour * 24 * 365 * 100)
+	}
+	return c.first.Add(time.Duration(float64(time.Since(c.started)) * c.speed))
+}
+
+// latest returns fixtures recorded at or before now, latest first.
+func latest(fixtures []fixture, now time.Time) []fixture {
+	n := sort.Search(len(fixtures), func(i int) bool { return fixtures[i].Time.After(now) })
+	ret := make([]fixture, n)
+	for i := range ret {
+		ret[i] = fixtures[n-i-1]
+	}
+	return ret
+}
+
+func (c *replay) Name() string {
+	return c.name
+}
+
+func (c *replay) GetSymbols() []Symbol {
+	return c.symbols
+}
+
+func (c *replay) GetCurrencies() []string {
+	return c.currencies
+}
+
+func (c *replay) FindSymbol(symbol string) (Symbol, bool) {
+	for _, s := range c.symbols {
+		if s.Symbol == symbol {
+			return s, true
+		}
+	}
+	return Symbol{}, false
+}
+
+// pick returns the index of the first wanted symbol matching s.
+func pick(wanted []Symbol, s Symbol) (int, bool) {
+	for i, w := range wanted {
+		if w.Is(s) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (c *replay) GetQuotes(ctx context.Context, currency string, symbol ...Symbol) ([]Quote, error) {
+	wanted := append([]Symbol(nil), symbol...)
+
+	fixtures := latest(c.quotes, c.now())
+	if len(fixtures) == 0 && len(c.quotes) > 0 {
+		fixtures = c.quotes[:1]
+	}
+
+	var ret []Quote
+	for _, f := range fixtures {
+		if f.Currency != currency || len(wanted) == 0 {
+			continue
+		}
+		if f.Error != "" {
+			if len(ret) == 0 {
+				return nil, errors.New(f.Error)
+			}
+			continue
+		}
+
+		var quotes []Quote
+		if err := json.Unmarshal(f.Result, &quotes); err != nil {
+			return nil, err
+		}
+		for _, q := range quotes {
+			i, ok := pick(wanted, q.Symbol)
+			if !ok {
+				continue
+			}
+			wanted = append(wanted[:i], wanted[i+1:]...)
+			q.Symbol.iconCache = c.iconCache
+			ret = append(ret, q)
+		}
+	}
+
+	return ret, nil
+}
+
+func (c *replay) GetOHLCV(ctx context.Context, currency string, interval time.Duration, start, end time.Time, symbol ...Symbol) ([]Ohlcv, error) {
+	for _, f := range latest(c.ohlcv, c.now()) {
+		if f.Currency != currency || f.Interval != interval {
+			continue
+		}
+		if f.Error != "" {
+			return nil, errors.New(f.Error)
+		}
+
+		var candles []Ohlcv
+		if err := json.Unmarshal(f.Result, &candles); err != nil {
+			return nil, err
+		}
+		var ret []Ohlcv
+		for _, o := range candles {
+			if _, ok := pick(symbol, o.Symbol); !ok {
+				continue
+			}
+			if o.TimeOpen.Before(start) || o.TimeOpen.After(end) {
+				continue
+			}
+			o.Symbol.iconCache = c.iconCache
+			ret = append(ret, o)
+		}
+		if len(ret) > 0 {
+			return ret, nil
+		}
+	}
+
+	return nil, nil
+}
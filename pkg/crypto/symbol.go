@@ -1,6 +1,7 @@
 package crypto
 
 import (
+	"context"
 	"image"
 	"net/http"
 	"time"
@@ -11,6 +12,7 @@ import (
 type Symbol struct {
 	iconCache Cache
 	Id        int
+	Provider  string
 	Name      string
 	Symbol    string
 	IconURL   string
@@ -31,14 +33,27 @@ type Quote struct {
 	PercentChange30D float64
 	MarketCap        float64
 	LastUpdated      time.Time
+	Sources          []string
+}
+
+// Info is coin metadata.
+type Info struct {
+	Symbol
+
+	Description string
+	Category    string
+	Tags        []string
+	Website     []string
+	Explorer    []string
+	SourceCode  []string
 }
 
 type Ohlcv struct {
 	Symbol
 
-	LastUpdated string
-	TimeOpen    string
-	TimeClose   string
+	LastUpdated time.Time
+	TimeOpen    time.Time
+	TimeClose   time.Time
 	Quote       map[string]OhlcvQuote
 }
 
@@ -52,14 +67,50 @@ type OhlcvQuote struct {
 	LastUpdated time.Time
 }
 
+// Is reports whether o denotes the same coin. Ids are only comparable between symbols
+// of the same provider, otherwise tickers are compared.
+func (s Symbol) Is(o Symbol) bool {
+	if s.Provider != "" && s.Provider == o.Provider && s.Id != 0 && o.Id != 0 {
+		return s.Id == o.Id
+	}
+	return s.Symbol == o.Symbol
+}
+
+// lookup resolves s among symbols of a provider. Symbols of the same provider are matched by Id
+// and by ticker otherwise.
+func lookup(symbols []Symbol, s Symbol) (Symbol, bool) {
+	byId := s.Id != 0 && len(symbols) > 0 && symbols[0].Provider == s.Provider
+	for _, sym := range symbols {
+		if byId && sym.Id == s.Id || !byId && sym.Symbol == s.Symbol {
+			return sym, true
+		}
+	}
+	return Symbol{}, false
+}
+
+// Icon returns the coin icon, see IconContext.
 func (s *Symbol) Icon() image.Image {
+	return s.IconContext(context.Background())
+}
+
+// IconContext returns the coin icon from the icon cache or downloads it. A blank image is returned on failure
+// or if the coin has no icon.
+func (s *Symbol) IconContext(ctx context.Context) image.Image {
+	if s.IconURL == "" {
+		return image.NewGray(image.Rectangle{Max: image.Point{32, 32}})
+	}
 	if s.iconCache != nil {
 		if img, err := s.iconCache.LoadImage(s.IconURL); err == nil {
 			return img
 		}
 	}
 
-	response, err := http.Get(s.IconURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.IconURL, nil)
+	if err != nil {
+		logger.Log.Warn().Str("url", s.IconURL).Err(err).Msg("No icon")
+		return image.NewGray(image.Rectangle{Max: image.Point{32, 32}})
+	}
+	response, err := httpClient().Do(req)
 	if err != nil {
 		logger.Log.Warn().Str("url", s.IconURL).Err(err).Msg("No icon")
 		return image.NewGray(image.Rectangle{Max: image.Point{32, 32}})
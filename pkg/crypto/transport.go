@@ -0,0 +1,275 @@
+package crypto
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gobwas/ws"
+)
+
+// HTTPConfig configures the HTTP client shared by all providers, streams and icon downloads.
+type HTTPConfig struct {
+	// Proxy is the proxy URL. Empty uses HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	Proxy string `json:"proxy,omitempty"`
+	// NoProxy is a comma separated list of hosts, domains and CIDRs that are reached directly
+	NoProxy string `json:"no_proxy,omitempty"`
+	// CAFile is a PEM bundle of root certificates trusted in addition to the system ones
+	CAFile string `json:"ca_file,omitempty"`
+	// DialTimeout bounds connecting and the TLS handshake
+	DialTimeout time.Duration `json:"dial_timeout,omitempty"`
+	// ResponseTimeout bounds waiting for response headers
+	ResponseTimeout time.Duration `json:"response_timeout,omitempty"`
+	UserAgent       string        `json:"user_agent,omitempty"`
+	// MaxConns limits connections per host, zero means no limit
+	MaxConns int `json:"max_conns,omitempty"`
+}
+
+// DefaultHTTPConfig is used until SetHTTPConfig is called.
+var DefaultHTTPConfig = HTTPConfig{
+	DialTimeout:     time.Second * 10,
+	ResponseTimeout: time.Second * 30,
+	UserAgent:       "CoinWatcher",
+}
+
+type transport struct {
+	config HTTPConfig
+	proxy  func(*http.Request) (*url.URL, error)
+	tls    *tls.Config
+	client *http.Client
+}
+
+var (
+	transportLock    sync.RWMutex
+	currentTransport = mustTransport(DefaultHTTPConfig)
+)
+
+// SetHTTPConfig replaces the shared HTTP client. Calls in flight finish with the previous one.
+func SetHTTPConfig(config HTTPConfig) error {
+	t, err := newTransport(config)
+	if err != nil {
+		return err
+	}
+
+	transportLock.Lock()
+	old := currentTransport
+	currentTransport = t
+	transportLock.Unlock()
+
+	old.client.CloseIdleConnections()
+	return nil
+}
+
+func getTransport() *transport {
+	transportLock.RLock()
+	defer transportLock.RUnlock()
+	return currentTransport
+}
+
+// httpClient returns the shared HTTP client.
+func httpClient() *http.Client {
+	return getTransport().client
+}
+
+func mustTransport(config HTTPConfig) *transport {
+	t, err := newTransport(config)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func newTransport(config HTTPConfig) (*transport, error) {
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = DefaultHTTPConfig.DialTimeout
+	}
+	if config.ResponseTimeout <= 0 {
+		config.ResponseTimeout = DefaultHTTPConfig.ResponseTimeout
+	}
+
+	t := &transport{
+		config: config,
+		proxy:  http.ProxyFromEnvironment,
+		tls:    &tls.Config{},
+	}
+
+	if config.Proxy != "" {
+		proxy, err := url.Parse(config.Proxy)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("bad proxy url %q", config.Proxy)
+		}
+		t.proxy = func(req *http.Request) (*url.URL, error) {
+			if noProxy(config.NoProxy, req.URL.Hostname()) {
+				return nil, nil
+			}
+			return proxy, nil
+		}
+	}
+
+	if config.CAFile != "" {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: no certificates", config.CAFile)
+		}
+		t.tls.RootCAs = pool
+	}
+
+	var rt http.RoundTripper = &http.Transport{
+		Proxy:                 t.proxy,
+		DialContext:           (&net.Dialer{Timeout: config.DialTimeout, KeepAlive: 30 * time.Second}).DialContext,
+		TLSClientConfig:       t.tls,
+		TLSHandshakeTimeout:   config.DialTimeout,
+		ResponseHeaderTimeout: config.ResponseTimeout,
+		MaxConnsPerHost:       config.MaxConns,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		ForceAttemptHTTP2:     true,
+	}
+	if config.UserAgent != "" {
+		rt = userAgent{RoundTripper: rt, agent: config.UserAgent}
+	}
+	t.client = &http.Client{Transport: rt}
+
+	return t, nil
+}
+
+type userAgent struct {
+	http.RoundTripper
+	agent string
+}
+
+func (t userAgent) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.agent)
+	}
+	return t.RoundTripper.RoundTrip(req)
+}
+
+// noProxy reports whether host is in the comma separated list of hosts, domains and CIDRs.
+func noProxy(list, host string) bool {
+	ip := net.ParseIP(host)
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			return true
+		case ip != nil:
+			if _, cidr, err := net.ParseCIDR(entry); err == nil && cidr.Contains(ip) {
+				return true
+			}
+			if ip.Equal(net.ParseIP(entry)) {
+				return true
+			}
+		default:
+			entry = strings.TrimPrefix(entry, ".")
+			host = strings.ToLower(host)
+			if host == entry || strings.HasSuffix(host, "."+entry) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wsDialer returns a WebSocket dialer that follows the shared HTTP client configuration.
+func wsDialer() ws.Dialer {
+	t := getTransport()
+	dialer := ws.Dialer{
+		Timeout:   t.config.DialTimeout,
+		TLSConfig: t.tls,
+		NetDial:   t.dialProxy,
+	}
+	if t.config.UserAgent != "" {
+		dialer.Header = ws.HandshakeHeaderHTTP(http.Header{"User-Agent": {t.config.UserAgent}})
+	}
+	return dialer
+}
+
+// dialProxy connects to addr, tunneling through the proxy with CONNECT if one applies.
+// HTTPS proxies are connected to with TLS, other schemes than HTTP are not supported.
+func (t *transport) dialProxy(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: t.config.DialTimeout}
+
+	host, _, _ := net.SplitHostPort(addr)
+	proxy, err := t.proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: addr}})
+	if err != nil || proxy == nil || host == "" {
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	port := "80"
+	switch proxy.Scheme {
+	case "http", "":
+	case "https":
+		port = "443"
+	default:
+		return nil, fmt.Errorf("proxy scheme %q is not supported for streams, use http or https", proxy.Scheme)
+	}
+	proxyAddr := proxy.Host
+	if proxy.Port() == "" {
+		proxyAddr = net.JoinHostPort(proxy.Hostname(), port)
+	}
+	conn, err := dialer.DialContext(ctx, network, proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+	if proxy.Scheme == "https" {
+		config := t.tls.Clone()
+		config.ServerName = proxy.Hostname()
+		tlsConn := tls.Client(conn, config)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("proxy: %w", err)
+		}
+		conn = tlsConn
+	}
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if user := proxy.User; user != nil {
+		password, _ := user.Password()
+		req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user.Username()+":"+password)))
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	response, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, errors.New("proxy: " + response.Status)
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
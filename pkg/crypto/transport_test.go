@@ -0,0 +1,142 @@
+package crypto
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// echoServer accepts connections and writes back what it reads.
+func echoServer(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+// connectProxy tunnels CONNECT requests and records the tunneled addresses.
+func connectProxy(tunneled chan<- string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		tunneled <- r.Host
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			target.Close()
+			return
+		}
+		conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		go func() {
+			defer target.Close()
+			io.Copy(target, buf)
+		}()
+		go func() {
+			defer conn.Close()
+			io.Copy(conn, target)
+		}()
+	})
+}
+
+func proxyTransport(proxy string, tlsConfig *tls.Config) *transport {
+	u, _ := url.Parse(proxy)
+	return &transport{
+		config: DefaultHTTPConfig,
+		proxy:  func(*http.Request) (*url.URL, error) { return u, nil },
+		tls:    tlsConfig,
+	}
+}
+
+func echo(t *testing.T, conn net.Conn) {
+	t.Helper()
+	defer conn.Close()
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 4)
+	if _, err := io.ReadFull(conn, b); err != nil || string(b) != "ping" {
+		t.Fatalf("unexpected echo %q %v", b, err)
+	}
+}
+
+func TestDialProxy(t *testing.T) {
+	target := echoServer(t)
+
+	for _, tc := range []struct {
+		name  string
+		proxy func(http.Handler) *httptest.Server
+	}{
+		{"http", httptest.NewServer},
+		{"https", httptest.NewTLSServer},
+	} {
+		tunneled := make(chan string, 1)
+		srv := tc.proxy(connectProxy(tunneled))
+		defer srv.Close()
+
+		pool := x509.NewCertPool()
+		if srv.Certificate() != nil {
+			pool.AddCert(srv.Certificate())
+		}
+		conn, err := proxyTransport(srv.URL, &tls.Config{RootCAs: pool}).dialProxy(context.Background(), "tcp", target)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		echo(t, conn)
+		if got := <-tunneled; got != target {
+			t.Fatalf("%s: tunneled to %s", tc.name, got)
+		}
+	}
+}
+
+func TestDialProxyErrors(t *testing.T) {
+	target := echoServer(t)
+
+	// A proxy that does not speak TLS fails the handshake instead of receiving CONNECT in plain text
+	plain := httptest.NewServer(connectProxy(make(chan string, 1)))
+	defer plain.Close()
+	if _, err := proxyTransport(strings.Replace(plain.URL, "http:", "https:", 1), &tls.Config{}).dialProxy(context.Background(), "tcp", target); err == nil {
+		t.Fatal("expected a TLS error")
+	}
+
+	// Untrusted proxy certificates are rejected
+	untrusted := httptest.NewUnstartedServer(connectProxy(make(chan string, 1)))
+	untrusted.Config.ErrorLog = log.New(io.Discard, "", 0)
+	untrusted.StartTLS()
+	defer untrusted.Close()
+	if _, err := proxyTransport(untrusted.URL, &tls.Config{}).dialProxy(context.Background(), "tcp", target); err == nil {
+		t.Fatal("expected an untrusted certificate error")
+	}
+
+	if _, err := proxyTransport("socks5://127.0.0.1:1080", &tls.Config{}).dialProxy(context.Background(), "tcp", target); err == nil || !strings.Contains(err.Error(), "socks5") {
+		t.Fatalf("expected an unsupported scheme error, got %v", err)
+	}
+}
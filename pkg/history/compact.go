@@ -0,0 +1,103 @@
+package history
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+)
+
+// Retention tells how long each resolution is kept before it is compacted into the next one.
+// Zero keeps the resolution forever.
+type Retention struct {
+	// Raw quotes are rolled up into hourly candles
+	Raw time.Duration `json:"raw"`
+	// Hourly candles are rolled up into daily candles
+	Hourly time.Duration `json:"hourly"`
+	// Daily candles are deleted
+	Daily time.Duration `json:"daily"`
+}
+
+// DefaultRetention keeps raw quotes for a week, hourly candles for three months and daily candles forever.
+var DefaultRetention = Retention{
+	Raw:    time.Hour * 24 * 7,
+	Hourly: time.Hour * 24 * 90,
+}
+
+// Compact applies retention to every series. The store is locked one partition at a time,
+// so appends and queries proceed while compacting.
+func (s *Store) Compact(ctx context.Context, retention Retention, now time.Time) error {
+	series, err := s.Series()
+	if err != nil {
+		return err
+	}
+
+	for _, sr := range series {
+		if retention.Raw > 0 {
+			cutoff := now.Add(-retention.Raw).Truncate(hourlyTier.step)
+			if err := s.compactTier(ctx, sr, rawTier, &hourlyTier, cutoff); err != nil {
+				return err
+			}
+		}
+		if retention.Hourly > 0 {
+			cutoff := now.Add(-retention.Hourly).Truncate(dailyTier.step)
+			if err := s.compactTier(ctx, sr, hourlyTier, &dailyTier, cutoff); err != nil {
+				return err
+			}
+		}
+		if retention.Daily > 0 {
+			if err := s.compactTier(ctx, sr, dailyTier, nil, now.Add(-retention.Daily)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compactTier moves points of a series older than cutoff from src into candles of dst.
+// They are deleted if dst is nil.
+func (s *Store) compactTier(ctx context.Context, sr Series, src tier, dst *tier, cutoff time.Time) error {
+	s.lock.RLock()
+	files, err := s.partitions(sr, src)
+	s.lock.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	last := src.partitionName(cutoff)
+	for _, file := range files {
+		if filepath.Base(file) > last {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.compactPartition(sr, file, src, dst, cutoff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) compactPartition(sr Series, file string, src tier, dst *tier, cutoff time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	points, err := readPartition(file, src)
+	if err != nil {
+		return err
+	}
+	n := 0
+	for n < len(points) && points[n].Time.Before(cutoff) {
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+
+	if dst != nil {
+		if err := s.appendTier(sr, *dst, rollup(points[:n], dst.step)); err != nil {
+			return err
+		}
+	}
+	return writePartition(file, src, points[n:])
+}
@@ -0,0 +1,116 @@
+package history
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRollup(t *testing.T) {
+	points := []Point{
+		pricePoint(at(0, 10), 1),
+		pricePoint(at(0, 20), 3),
+		pricePoint(at(0, 50), 2),
+		pricePoint(at(1, 10), 5),
+	}
+	want := []Point{
+		{Time: at(0, 0), Open: 1, High: 3, Low: 1, Price: 2},
+		{Time: at(1, 0), Open: 5, High: 5, Low: 5, Price: 5},
+	}
+	if got := rollup(points, time.Hour); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	want = []Point{{Time: at(0, 0), Open: 1, High: 5, Low: 1, Price: 5}}
+	if got := rollup(points, time.Hour*24); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	s := openStore(t)
+	feb := func(hour, min int) time.Time { return time.Date(2021, 2, 20, hour, min, 0, 0, time.UTC) }
+	mar := time.Date(2021, 3, 10, 0, 0, 0, 0, time.UTC)
+	appendPoints(t, s,
+		pricePoint(at(0, 10), 1), pricePoint(at(0, 20), 3), pricePoint(at(0, 50), 2), pricePoint(at(1, 10), 5),
+		pricePoint(feb(10, 5), 10), pricePoint(feb(10, 30), 12), pricePoint(feb(10, 45), 9), pricePoint(feb(11, 0), 11),
+		pricePoint(mar, 20),
+	)
+
+	// January is older than hourly retention, February older than raw retention
+	now := time.Date(2021, 3, 15, 0, 0, 0, 0, time.UTC)
+	retention := Retention{Raw: time.Hour * 24 * 7, Hourly: time.Hour * 24 * 30}
+	if err := s.Compact(context.Background(), retention, now); err != nil {
+		t.Fatal(err)
+	}
+
+	files := func(tr tier) []string {
+		paths, err := s.partitions(testSeries, tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ret []string
+		for _, p := range paths {
+			ret = append(ret, filepath.Base(p))
+		}
+		return ret
+	}
+	for _, tc := range []struct {
+		tier tier
+		want []string
+	}{
+		{rawTier, []string{"2021-03.dat"}},
+		{hourlyTier, []string{"2021-02.dat"}},
+		{dailyTier, []string{"2021.dat"}},
+	} {
+		if got := files(tc.tier); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("tier %q has partitions %v, want %v", tc.tier.dir, got, tc.want)
+		}
+	}
+
+	// Range returns the finest resolution kept for each part of the range
+	points, err := s.Range(testSeries, at(0, 0), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Point{
+		{Time: at(0, 0), Open: 1, High: 5, Low: 1, Price: 5},
+		{Time: feb(10, 0), Open: 10, High: 12, Low: 9, Price: 9},
+		{Time: feb(11, 0), Open: 11, High: 11, Low: 11, Price: 11},
+		pricePoint(mar, 20),
+	}
+	if len(points) != len(want) {
+		t.Fatalf("got %+v, want %+v", points, want)
+	}
+	for i := range want {
+		got := points[i]
+		if !got.Time.Equal(want[i].Time) {
+			t.Errorf("point %d at %v, want %v", i, got.Time.UTC(), want[i].Time)
+		}
+		got.Time = want[i].Time
+		if got != want[i] {
+			t.Errorf("point %d is %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	// Daily candles are deleted after their retention
+	retention.Daily = time.Hour * 24 * 30
+	if err := s.Compact(context.Background(), retention, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(s.tierDir(testSeries, dailyTier), "2021.dat")); !os.IsNotExist(err) {
+		t.Errorf("daily partition is not deleted: %v", err)
+	}
+}
+
+func TestCompactCanceled(t *testing.T) {
+	s := openStore(t)
+	appendPoints(t, s, pricePoint(at(0, 0), 1))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.Compact(ctx, DefaultRetention, at(0, 0).AddDate(1, 0, 0)); err != context.Canceled {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
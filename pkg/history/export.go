@@ -0,0 +1,208 @@
+package history
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats
+const (
+	CSV   = "csv"
+	JSONL = "jsonl"
+)
+
+// ExportOptions select what Export writes.
+type ExportOptions struct {
+	// Symbols to export, every symbol recorded in Currency if empty
+	Symbols  []string
+	Currency string
+	// From and To bound the time range, zero To means now
+	From time.Time
+	To   time.Time
+	// Location of exported times, UTC if nil
+	Location *time.Location
+	// Format is CSV or JSONL
+	Format string
+}
+
+type exportRow struct {
+	Time             string  `json:"time"`
+	Symbol           string  `json:"symbol"`
+	Name             string  `json:"name,omitempty"`
+	Provider         string  `json:"provider,omitempty"`
+	Id               int     `json:"id,omitempty"`
+	Currency         string  `json:"currency"`
+	Open             float64 `json:"open"`
+	High             float64 `json:"high"`
+	Low              float64 `json:"low"`
+	Price            float64 `json:"price"`
+	Volume24H        float64 `json:"volume_24h"`
+	Volume7D         float64 `json:"volume_7d"`
+	Volume30D        float64 `json:"volume_30d"`
+	Volume24Hbase    float64 `json:"volume_24h_base"`
+	Volume24Hquote   float64 `json:"volume_24h_quote"`
+	MarketCap        float64 `json:"market_cap"`
+	PercentChange1H  float64 `json:"percent_change_1h"`
+	PercentChange24H float64 `json:"percent_change_24h"`
+	PercentChange7D  float64 `json:"percent_change_7d"`
+	PercentChange30D float64 `json:"percent_change_30d"`
+}
+
+var csvHeader = []string{
+	"time", "symbol", "name", "provider", "id", "currency", "open", "high", "low", "price",
+	"volume_24h", "volume_7d", "volume_30d", "volume_24h_base", "volume_24h_quote", "market_cap",
+	"percent_change_1h", "percent_change_24h", "percent_change_7d", "percent_change_30d",
+}
+
+func (r exportRow) csv() []string {
+	id := ""
+	if r.Id != 0 {
+		id = strconv.Itoa(r.Id)
+	}
+	ret := []string{r.Time, r.Symbol, r.Name, r.Provider, id, r.Currency}
+	for _, v := range []float64{r.Open, r.High, r.Low, r.Price,
+		r.Volume24H, r.Volume7D, r.Volume30D, r.Volume24Hbase, r.Volume24Hquote, r.MarketCap,
+		r.PercentChange1H, r.PercentChange24H, r.PercentChange7D, r.PercentChange30D} {
+		ret = append(ret, strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	return ret
+}
+
+// FormatOf guesses the export format from a file name.
+func FormatOf(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jsonl", ".ndjson", ".json":
+		return JSONL
+	}
+	return CSV
+}
+
+// ParseTime parses a date, a date with time or an RFC3339 timestamp. Times without a zone are in loc.
+func ParseTime(s string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", "2006-01-02 15:04:05", "2006-01-02T15:04:05"} {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("bad time %q, expected YYYY-MM-DD [hh:mm]", s)
+}
+
+// Export writes recorded points one row per point ordered by symbol and time.
+// It returns the number of rows written.
+func (s *Store) Export(w io.Writer, opts ExportOptions) (int, error) {
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	if opts.To.IsZero() {
+		opts.To = time.Now()
+	}
+	currency := strings.ToUpper(opts.Currency)
+
+	all, err := s.Series()
+	if err != nil {
+		return 0, err
+	}
+	var series []Series
+	for _, sr := range all {
+		if sr.Currency != currency {
+			continue
+		}
+		for _, symbol := range opts.Symbols {
+			if sr.Symbol == symbol {
+				series = append(series, sr)
+				break
+			}
+		}
+		if len(opts.Symbols) == 0 {
+			series = append(series, sr)
+		}
+	}
+	sort.Slice(series, func(i, j int) bool {
+		a, b := series[i], series[j]
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		return a.Id < b.Id
+	})
+
+	var (
+		write func(exportRow) error
+		flush = func() error { return nil }
+	)
+	switch opts.Format {
+	case CSV, "":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return 0, err
+		}
+		write = func(r exportRow) error {
+			return cw.Write(r.csv())
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	case JSONL:
+		enc := json.NewEncoder(w)
+		write = func(r exportRow) error {
+			return enc.Encode(r)
+		}
+	default:
+		return 0, fmt.Errorf("unknown export format %q", opts.Format)
+	}
+
+	n := 0
+	for _, sr := range series {
+		points, err := s.Range(sr, opts.From, opts.To)
+		if err != nil {
+			return n, err
+		}
+		name := s.Name(sr)
+		for _, p := range points {
+			err := write(exportRow{
+				Time:             p.Time.In(opts.Location).Format(time.RFC3339),
+				Symbol:           sr.Symbol,
+				Name:             name,
+				Provider:         sr.Provider,
+				Id:               sr.Id,
+				Currency:         currency,
+				Open:             p.Open,
+				High:             p.High,
+				Low:              p.Low,
+				Price:            p.Price,
+				Volume24H:        p.Volume24H,
+				Volume7D:         p.Volume7D,
+				Volume30D:        p.Volume30D,
+				Volume24Hbase:    p.Volume24Hbase,
+				Volume24Hquote:   p.Volume24Hquote,
+				MarketCap:        p.MarketCap,
+				PercentChange1H:  p.PercentChange1H,
+				PercentChange24H: p.PercentChange24H,
+				PercentChange7D:  p.PercentChange7D,
+				PercentChange30D: p.PercentChange30D,
+			})
+			if err != nil {
+				return n, err
+			}
+			n++
+		}
+	}
+	return n, flush()
+}
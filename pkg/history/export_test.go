@@ -0,0 +1,88 @@
+package history
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+)
+
+// exportStore records BTC and ETH in USD and EUR, and DOGE in USD.
+func exportStore(t *testing.T) *Store {
+	t.Helper()
+	s := openStore(t)
+	quote := func(symbol crypto.Symbol, price float64, t time.Time) crypto.Quote {
+		return crypto.Quote{
+			Symbol:           symbol,
+			Price:            price,
+			Volume24H:        price * 1000,
+			Volume7D:         price * 7000,
+			Volume30D:        price * 30000,
+			Volume24Hbase:    1000,
+			Volume24Hquote:   price * 1000,
+			MarketCap:        price * 1e6,
+			PercentChange1H:  0.1,
+			PercentChange24H: -2.5,
+			PercentChange7D:  7,
+			PercentChange30D: 30,
+			LastUpdated:      t,
+		}
+	}
+	btc := crypto.Symbol{Symbol: "BTC", Name: "Bitcoin", Provider: "coingecko", Id: 1}
+	eth := crypto.Symbol{Symbol: "ETH", Name: "Ethereum", Provider: "coingecko", Id: 2}
+	doge := crypto.Symbol{Symbol: "DOGE", Name: "Dogecoin", Provider: "coingecko", Id: 3}
+	for _, tc := range []struct {
+		currency string
+		quotes   []crypto.Quote
+	}{
+		{"USD", []crypto.Quote{
+			quote(btc, 29000, at(23, 30).AddDate(0, 0, -1)),
+			quote(btc, 29500.5, at(0, 30)),
+			quote(eth, 730.25, at(0, 30)),
+			quote(doge, 0.005, at(0, 30)),
+			// Outside of the exported range
+			quote(btc, 30000, at(2, 0)),
+		}},
+		{"EUR", []crypto.Quote{quote(btc, 24000, at(0, 30))}},
+	} {
+		if err := s.AppendQuotes(tc.currency, tc.quotes...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestExport(t *testing.T) {
+	s := exportStore(t)
+	for _, format := range []string{CSV, JSONL} {
+		var buf bytes.Buffer
+		n, err := s.Export(&buf, ExportOptions{
+			Symbols:  []string{"ETH", "BTC"},
+			Currency: "usd",
+			From:     at(0, 0).AddDate(0, 0, -1),
+			To:       at(1, 0),
+			Location: time.FixedZone("CET", 3600),
+			Format:   format,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 3 {
+			t.Errorf("%s: exported %d rows, want 3", format, n)
+		}
+		want, err := os.ReadFile(filepath.Join("testdata", "export."+format))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("%s: got\n%s\nwant\n%s", format, buf.Bytes(), want)
+		}
+	}
+
+	if _, err := s.Export(&bytes.Buffer{}, ExportOptions{Currency: "USD", Format: "xml"}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
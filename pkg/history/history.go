@@ -0,0 +1,386 @@
+// Package history keeps every fetched quote in a local time series store.
+//
+// Each series, a coin quoted in a currency, is stored in partition files of fixed size records
+// sorted by time. Raw quotes are partitioned by month, older quotes are compacted into hourly
+// and daily candles according to the Retention:
+//
+//	<dir>/<currency>/<symbol>@<provider>-<id>/<yyyy-mm>.dat
+//	<dir>/<currency>/<symbol>@<provider>-<id>/1h/<yyyy-mm>.dat
+//	<dir>/<currency>/<symbol>@<provider>-<id>/1d/<yyyy>.dat
+//	<dir>/<currency>/<symbol>@<provider>-<id>/name
+//
+// Series of coins without a provider id are named by the symbol alone.
+package history
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+)
+
+const (
+	partitionExt = ".dat"
+	// nameFile holds the coin name of a series
+	nameFile = "name"
+)
+
+// tier is a resolution of stored points.
+type tier struct {
+	// dir is the subdirectory of the series, empty for raw quotes
+	dir string
+	// step is the candle duration, zero for raw quotes
+	step time.Duration
+	// layout names partitions by their start time
+	layout string
+}
+
+var (
+	rawTier    = tier{layout: "2006-01"}
+	hourlyTier = tier{dir: "1h", step: time.Hour, layout: "2006-01"}
+	dailyTier  = tier{dir: "1d", step: time.Hour * 24, layout: "2006"}
+
+	// tiers from the finest to the coarsest
+	tiers = []tier{rawTier, hourlyTier, dailyTier}
+)
+
+func (t tier) partitionName(tm time.Time) string {
+	return tm.UTC().Format(t.layout) + partitionExt
+}
+
+// Series identifies a stored time series, a coin quoted in a currency. Coins are told apart by the
+// Provider that listed them and its Id, so that coins sharing a ticker have separate series.
+// Provider is empty for coins without a provider id.
+type Series struct {
+	Symbol   string
+	Provider string
+	Id       int
+	Currency string
+}
+
+// SeriesOf returns the series of a coin quoted in currency.
+func SeriesOf(symbol crypto.Symbol, currency string) Series {
+	ret := Series{
+		Symbol:   symbol.Symbol,
+		Currency: strings.ToUpper(currency),
+	}
+	if symbol.Provider != "" && symbol.Id != 0 {
+		ret.Provider, ret.Id = symbol.Provider, symbol.Id
+	}
+	return ret
+}
+
+// name is the directory of the series in its currency directory.
+func (sr Series) name() string {
+	if sr.Provider == "" {
+		return url.PathEscape(sr.Symbol)
+	}
+	return fmt.Sprintf("%s@%s-%d", url.QueryEscape(sr.Symbol), url.QueryEscape(sr.Provider), sr.Id)
+}
+
+// parseSeries parses a series directory name.
+func parseSeries(name, currency string) (Series, error) {
+	if i := strings.LastIndex(name, "@"); i >= 0 {
+		if j := strings.LastIndex(name[i:], "-"); j > 0 {
+			symbol, err1 := url.QueryUnescape(name[:i])
+			provider, err2 := url.QueryUnescape(name[i+1 : i+j])
+			id, err3 := strconv.Atoi(name[i+j+1:])
+			if err1 == nil && err2 == nil && err3 == nil && provider != "" {
+				return Series{Symbol: symbol, Provider: provider, Id: id, Currency: currency}, nil
+			}
+		}
+	}
+	symbol, err := url.PathUnescape(name)
+	return Series{Symbol: symbol, Currency: currency}, err
+}
+
+// Store is a time series store of quotes. It is safe for concurrent use.
+type Store struct {
+	dir   string
+	lock  sync.RWMutex
+	names map[Series]string
+	// held is the lock file while the store is locked
+	held *os.File
+}
+
+// Open opens the store in dir, creating it if needed.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &Store{
+		dir:   dir,
+		names: make(map[Series]string),
+	}
+	return s, nil
+}
+
+func (s *Store) tierDir(sr Series, t tier) string {
+	return filepath.Join(s.dir, url.PathEscape(strings.ToUpper(sr.Currency)), sr.name(), t.dir)
+}
+
+// partitions returns partition files of a series tier in chronological order.
+func (s *Store) partitions(sr Series, t tier) ([]string, error) {
+	dir := s.tierDir(sr, t)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ret []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, partitionExt) {
+			continue
+		}
+		if _, err := time.Parse(t.layout, strings.TrimSuffix(name, partitionExt)); err != nil {
+			continue
+		}
+		ret = append(ret, filepath.Join(dir, name))
+	}
+	sort.Strings(ret)
+	return ret, nil
+}
+
+// AppendQuotes stores quotes in currency in the series of their coins.
+func (s *Store) AppendQuotes(currency string, quotes ...crypto.Quote) error {
+	bySeries := make(map[Series][]Point)
+	names := make(map[Series]string)
+	for _, q := range quotes {
+		sr := SeriesOf(q.Symbol, currency)
+		bySeries[sr] = append(bySeries[sr], FromQuote(q))
+		if q.Symbol.Name != "" {
+			names[sr] = q.Symbol.Name
+		}
+	}
+	for sr, points := range bySeries {
+		if err := s.Append(sr, points...); err != nil {
+			return err
+		}
+		if err := s.setName(sr, names[sr]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setName stores the coin name of a series unless it is known.
+func (s *Store) setName(sr Series, name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if name == "" || s.names[sr] == name {
+		return nil
+	}
+	if err := os.WriteFile(filepath.Join(s.tierDir(sr, rawTier), nameFile), []byte(name), 0o644); err != nil {
+		return err
+	}
+	s.names[sr] = name
+	return nil
+}
+
+// Name returns the coin name of a series, or an empty string if it was not recorded.
+func (s *Store) Name(sr Series) string {
+	s.lock.RLock()
+	name, ok := s.names[sr]
+	s.lock.RUnlock()
+	if ok {
+		return name
+	}
+	b, err := os.ReadFile(filepath.Join(s.tierDir(sr, rawTier), nameFile))
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// Append stores raw points of a series. Points at times that are already stored are ignored.
+func (s *Store) Append(sr Series, points ...Point) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.appendTier(sr, rawTier, points)
+}
+
+func (s *Store) appendTier(sr Series, t tier, points []Point) error {
+	if sr.Symbol == "" || sr.Currency == "" {
+		return fmt.Errorf("no symbol or currency")
+	}
+	if len(points) == 0 {
+		return nil
+	}
+	points = append([]Point(nil), points...)
+	sortPoints(points)
+
+	dir := s.tierDir(sr, t)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for len(points) > 0 {
+		name := t.partitionName(points[0].Time)
+		n := 1
+		for n < len(points) && t.partitionName(points[n].Time) == name {
+			n++
+		}
+		if err := appendPartition(filepath.Join(dir, name), t, points[:n]); err != nil {
+			return err
+		}
+		points = points[n:]
+	}
+	return nil
+}
+
+func (s *Store) rangeTier(sr Series, t tier, from, to time.Time) ([]Point, error) {
+	files, err := s.partitions(sr, t)
+	if err != nil {
+		return nil, err
+	}
+	first, last := t.partitionName(from), t.partitionName(to)
+
+	var ret []Point
+	for _, file := range files {
+		name := filepath.Base(file)
+		if name < first || name > last {
+			continue
+		}
+		points, err := readRange(file, t, from, to)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, points...)
+	}
+	return ret, nil
+}
+
+// Range returns points of a series between from and to inclusive at the finest resolution
+// that is still kept for each part of the range.
+func (s *Store) Range(sr Series, from, to time.Time) ([]Point, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var ret []Point
+	for _, t := range tiers {
+		if len(ret) > 0 {
+			to = ret[0].Time.Add(-time.Nanosecond)
+		}
+		if to.Before(from) {
+			break
+		}
+		points, err := s.rangeTier(sr, t, from, to)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(points, ret...)
+	}
+	return ret, nil
+}
+
+// Candles returns candles of step between from and to. Parts of the range that were compacted
+// into coarser resolution than step are returned at that resolution.
+func (s *Store) Candles(sr Series, from, to time.Time, step time.Duration) ([]Point, error) {
+	points, err := s.Range(sr, from, to)
+	if err != nil || step <= 0 {
+		return points, err
+	}
+	return rollup(points, step), nil
+}
+
+// Last returns up to n latest points of a series.
+func (s *Store) Last(sr Series, n int) ([]Point, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var ret []Point
+	for _, t := range tiers {
+		files, err := s.partitions(sr, t)
+		if err != nil {
+			return nil, err
+		}
+		for i := len(files) - 1; i >= 0 && len(ret) < n; i-- {
+			var before time.Time
+			if len(ret) > 0 {
+				before = ret[0].Time
+			}
+			points, err := readLast(files[i], t, before, n-len(ret))
+			if err != nil {
+				return nil, err
+			}
+			ret = append(points, ret...)
+		}
+	}
+	return ret, nil
+}
+
+// At returns the latest point of a series at or before t.
+func (s *Store) At(sr Series, t time.Time) (Point, bool, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var (
+		ret   Point
+		found bool
+	)
+	for _, tr := range tiers {
+		files, err := s.partitions(sr, tr)
+		if err != nil {
+			return Point{}, false, err
+		}
+		last := tr.partitionName(t)
+		for i := len(files) - 1; i >= 0; i-- {
+			if filepath.Base(files[i]) > last {
+				continue
+			}
+			p, ok, err := readAt(files[i], tr, t)
+			if err != nil {
+				return Point{}, false, err
+			}
+			if ok {
+				if !found || p.Time.After(ret.Time) {
+					ret, found = p, true
+				}
+				break
+			}
+		}
+	}
+	return ret, found, nil
+}
+
+// Series lists stored series.
+func (s *Store) Series() ([]Series, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	currencies, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var ret []Series
+	for _, c := range currencies {
+		if !c.IsDir() {
+			continue
+		}
+		currency, err := url.PathUnescape(c.Name())
+		if err != nil {
+			continue
+		}
+		symbols, err := os.ReadDir(filepath.Join(s.dir, c.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, sym := range symbols {
+			sr, err := parseSeries(sym.Name(), currency)
+			if err != nil || !sym.IsDir() {
+				continue
+			}
+			ret = append(ret, sr)
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,239 @@
+package history
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+)
+
+var testSeries = Series{Symbol: "BTC", Provider: "coingecko", Id: 1, Currency: "USD"}
+
+// at returns a time on the 1st of January 2021 UTC.
+func at(hour, min int) time.Time {
+	return time.Date(2021, 1, 1, hour, min, 0, 0, time.UTC)
+}
+
+func pricePoint(t time.Time, price float64) Point {
+	return Point{Time: t, Open: price, High: price, Low: price, Price: price}
+}
+
+func openStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func appendPoints(t *testing.T, s *Store, points ...Point) {
+	t.Helper()
+	if err := s.Append(testSeries, points...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// prices lists the times and prices of points.
+func prices(points []Point) map[time.Time]float64 {
+	ret := make(map[time.Time]float64)
+	for _, p := range points {
+		ret[p.Time.UTC()] = p.Price
+	}
+	return ret
+}
+
+func TestPointRoundTrip(t *testing.T) {
+	want := Point{
+		Time:             time.Unix(0, 1609459200123456789),
+		Open:             1,
+		High:             2,
+		Low:              3,
+		Price:            4,
+		Volume24H:        5,
+		Volume7D:         6,
+		Volume30D:        7,
+		Volume24Hbase:    8,
+		Volume24Hquote:   9,
+		MarketCap:        10,
+		PercentChange1H:  11,
+		PercentChange24H: 12,
+		PercentChange7D:  13,
+		PercentChange30D: 14,
+	}
+	b := rawTier.encode([]Point{want, want})
+	if len(b) != 2*recordSize {
+		t.Fatalf("encoded %d bytes, want %d", len(b), 2*recordSize)
+	}
+	got := rawTier.decode(append(b, 1, 2, 3))
+	if len(got) != 2 {
+		t.Fatalf("decoded %d points, want 2", len(got))
+	}
+	if !reflect.DeepEqual(got[1], want) {
+		t.Errorf("got %+v, want %+v", got[1], want)
+	}
+
+	s := openStore(t)
+	appendPoints(t, s, want)
+	stored, err := s.Range(testSeries, want.Time, want.Time)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 || !reflect.DeepEqual(stored[0], want) {
+		t.Errorf("stored %+v, want %+v", stored, want)
+	}
+}
+
+func TestAppendQuotes(t *testing.T) {
+	s := openStore(t)
+	q := crypto.Quote{
+		Symbol:      crypto.Symbol{Symbol: "BTC", Name: "Bitcoin", Provider: "coingecko", Id: 1},
+		Price:       100,
+		LastUpdated: at(0, 0),
+	}
+	if err := s.AppendQuotes("usd", q); err != nil {
+		t.Fatal(err)
+	}
+	series, err := s.Series()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(series, []Series{testSeries}) {
+		t.Errorf("got series %v, want %v", series, []Series{testSeries})
+	}
+	if name := s.Name(testSeries); name != "Bitcoin" {
+		t.Errorf("got name %q", name)
+	}
+}
+
+func TestRange(t *testing.T) {
+	s := openStore(t)
+	dec := time.Date(2020, 12, 31, 23, 0, 0, 0, time.UTC)
+	feb := time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)
+	// Out of order and across three monthly partitions
+	appendPoints(t, s, pricePoint(feb, 3), pricePoint(dec, 1), pricePoint(at(0, 0), 2))
+	appendPoints(t, s, pricePoint(at(0, 0), 20))
+
+	for _, tc := range []struct {
+		name     string
+		from, to time.Time
+		want     map[time.Time]float64
+	}{
+		{"all", dec, feb, map[time.Time]float64{dec: 1, at(0, 0): 2, feb: 3}},
+		{"inner", dec.Add(time.Minute), feb.Add(-time.Minute), map[time.Time]float64{at(0, 0): 2}},
+		{"last partitions", at(0, 0), feb.Add(time.Hour), map[time.Time]float64{at(0, 0): 2, feb: 3}},
+		{"none", at(1, 0), at(2, 0), map[time.Time]float64{}},
+	} {
+		points, err := s.Range(testSeries, tc.from, tc.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := prices(points); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+		for i := 1; i < len(points); i++ {
+			if !points[i-1].Time.Before(points[i].Time) {
+				t.Errorf("%s: points are not sorted: %v", tc.name, points)
+			}
+		}
+	}
+}
+
+func TestLast(t *testing.T) {
+	s := openStore(t)
+	dec := time.Date(2020, 12, 31, 23, 0, 0, 0, time.UTC)
+	appendPoints(t, s, pricePoint(dec, 1), pricePoint(at(0, 0), 2), pricePoint(at(1, 0), 3))
+
+	for _, tc := range []struct {
+		n    int
+		want []float64
+	}{
+		{1, []float64{3}},
+		{2, []float64{2, 3}},
+		{3, []float64{1, 2, 3}},
+		{10, []float64{1, 2, 3}},
+	} {
+		points, err := s.Last(testSeries, tc.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []float64
+		for _, p := range points {
+			got = append(got, p.Price)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Last(%d) = %v, want %v", tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestAt(t *testing.T) {
+	s := openStore(t)
+	dec := time.Date(2020, 12, 31, 23, 0, 0, 0, time.UTC)
+	appendPoints(t, s, pricePoint(dec, 1), pricePoint(at(1, 0), 2))
+
+	for _, tc := range []struct {
+		name  string
+		t     time.Time
+		found bool
+		want  float64
+	}{
+		{"before", dec.Add(-time.Minute), false, 0},
+		{"at", dec, true, 1},
+		{"between", at(0, 30), true, 1},
+		{"at the last", at(1, 0), true, 2},
+		{"after", at(5, 0), true, 2},
+	} {
+		p, found, err := s.At(testSeries, tc.t)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found != tc.found || p.Price != tc.want {
+			t.Errorf("%s: got %v, %v, want %v, %v", tc.name, p.Price, found, tc.want, tc.found)
+		}
+	}
+}
+
+func TestConcurrentAppendRange(t *testing.T) {
+	s := openStore(t)
+	const n = 100
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; i++ {
+			if err := s.Append(testSeries, pricePoint(at(0, 0).Add(time.Duration(i)*time.Hour*12), float64(i))); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; i++ {
+			points, err := s.Range(testSeries, at(0, 0), at(0, 0).AddDate(1, 0, 0))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			for j, p := range points {
+				if p.Price != float64(j) {
+					t.Errorf("read a partially written point %d: %+v", j, p)
+					return
+				}
+			}
+		}
+	}()
+	wg.Wait()
+
+	points, err := s.Range(testSeries, at(0, 0), at(0, 0).AddDate(1, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != n {
+		t.Errorf("got %d points, want %d", len(points), n)
+	}
+}
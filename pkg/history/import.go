@@ -0,0 +1,328 @@
+package history
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+)
+
+// Columns names CSV columns of imported fields. Empty names are detected from common headers.
+type Columns struct {
+	Time      string
+	Open      string
+	High      string
+	Low       string
+	Close     string
+	Volume    string
+	MarketCap string
+}
+
+// ImportOptions configure Import.
+type ImportOptions struct {
+	Symbol   crypto.Symbol
+	Currency string
+	Columns  Columns
+	// Location of times without a zone, UTC if nil
+	Location *time.Location
+	// Interval of imported candles, detected from row times if zero
+	Interval time.Duration
+}
+
+// Rejected is a row that could not be imported.
+type Rejected struct {
+	Line int
+	Err  error
+}
+
+func (r Rejected) Error() string {
+	return fmt.Sprintf("line %d: %v", r.Line, r.Err)
+}
+
+// ImportResult reports what Import did.
+type ImportResult struct {
+	Imported   int
+	Duplicates int
+	Rejected   []Rejected
+}
+
+// Header names of common historical price exports, normalized by normalizeHeader.
+var importHeaders = map[string][]string{
+	"time":      {"date", "time", "timestamp", "datetime", "timeopen", "opentime", "snappedat", "unix"},
+	"open":      {"open"},
+	"high":      {"high"},
+	"low":       {"low"},
+	"close":     {"close", "price", "last", "adjclose"},
+	"volume":    {"volume", "totalvolume", "vol"},
+	"marketcap": {"marketcap", "totalmarketcap"},
+}
+
+var importTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 03-PM",
+	"2006/01/02",
+	"01/02/2006",
+	"02.01.2006",
+	"Jan 02, 2006",
+	"Jan 2, 2006",
+}
+
+func normalizeHeader(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+// columnIndex finds the column of a field in header. The configured name wins over detected ones.
+func columnIndex(header []string, name string, candidates []string) int {
+	if name != "" {
+		candidates = []string{normalizeHeader(name)}
+	}
+	for _, c := range candidates {
+		for i, h := range header {
+			if normalizeHeader(h) == c {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func parseImportTime(s string, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if n > 1e12 {
+			return time.UnixMilli(n), nil
+		}
+		return time.Unix(n, 0), nil
+	}
+	for _, layout := range importTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("bad time %q", s)
+}
+
+// parseImportNumber parses numbers with currency signs, thousand separators or decimal commas.
+// A single comma after any dots is decimal if there are dots or fields are separated by semicolons,
+// as in 1.234,56. Otherwise commas and repeated dots separate thousands.
+func parseImportNumber(s string, comma rune) (float64, error) {
+	s = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(s), "$€£"))
+	s = strings.NewReplacer(" ", "", "\u00a0", "", "'", "").Replace(s)
+	dot, sep := strings.LastIndex(s, "."), strings.LastIndex(s, ",")
+	if strings.Count(s, ",") == 1 && sep > dot && (dot >= 0 || comma == ';') {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.Replace(s, ",", ".", 1)
+	} else {
+		s = strings.ReplaceAll(s, ",", "")
+		if strings.Count(s, ".") > 1 {
+			s = strings.ReplaceAll(s, ".", "")
+		}
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// detectInterval returns the shortest time between consecutive rows.
+func detectInterval(points []Point) time.Duration {
+	var ret time.Duration
+	for i := 1; i < len(points); i++ {
+		if d := points[i].Time.Sub(points[i-1].Time); d > 0 && (ret == 0 || d < ret) {
+			ret = d
+		}
+	}
+	return ret
+}
+
+// Import backfills a series from historical prices in CSV. The first row that has time and close columns
+// is the header, rows before it are skipped. Daily and hourly candles are stored as such,
+// finer rows as raw quotes. Rows at times that are already stored are counted as duplicates.
+func (s *Store) Import(r io.Reader, opts ImportOptions) (ImportResult, error) {
+	var result ImportResult
+	if opts.Symbol.Symbol == "" || opts.Currency == "" {
+		return result, errors.New("no symbol or currency")
+	}
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return result, err
+	}
+	reader := csv.NewReader(strings.NewReader(string(data)))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+	firstLine := strings.SplitN(string(data), "\n", 2)[0]
+	if strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		reader.Comma = ';'
+	}
+
+	var (
+		columns = map[string]int{}
+		points  []Point
+	)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) && len(columns) > 0 {
+				result.Rejected = append(result.Rejected, Rejected{Line: parseErr.Line, Err: parseErr.Err})
+			}
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		if len(columns) == 0 {
+			names := map[string]string{
+				"time": opts.Columns.Time, "open": opts.Columns.Open, "high": opts.Columns.High, "low": opts.Columns.Low,
+				"close": opts.Columns.Close, "volume": opts.Columns.Volume, "marketcap": opts.Columns.MarketCap,
+			}
+			volume := append([]string{"volume" + strings.ToLower(opts.Currency)}, importHeaders["volume"]...)
+			for field, candidates := range importHeaders {
+				if field == "volume" {
+					candidates = volume
+				}
+				columns[field] = columnIndex(record, names[field], candidates)
+			}
+			if columns["time"] < 0 || columns["close"] < 0 {
+				columns = map[string]int{}
+			}
+			continue
+		}
+
+		p, err := importRow(record, columns, opts.Location, reader.Comma)
+		if err != nil {
+			result.Rejected = append(result.Rejected, Rejected{Line: line, Err: err})
+			continue
+		}
+		points = append(points, p)
+	}
+	if len(columns) == 0 {
+		return result, errors.New("no time and close columns found")
+	}
+	if len(points) == 0 {
+		return result, nil
+	}
+
+	sortPoints(points)
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = detectInterval(points)
+	}
+	t := rawTier
+	switch {
+	case interval >= dailyTier.step:
+		t = dailyTier
+	case interval >= hourlyTier.step:
+		t = hourlyTier
+	}
+	for i, p := range points {
+		switch t {
+		case dailyTier:
+			// Dates are days in the given location
+			y, m, d := p.Time.In(opts.Location).Date()
+			points[i].Time = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		case hourlyTier:
+			points[i].Time = p.Time.Truncate(t.step)
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	sr := SeriesOf(opts.Symbol, opts.Currency)
+	stored, err := s.rangeTier(sr, t, points[0].Time, points[len(points)-1].Time)
+	if err != nil {
+		return result, err
+	}
+	seen := make(map[int64]struct{}, len(stored))
+	for _, p := range stored {
+		seen[p.Time.UnixNano()] = struct{}{}
+	}
+	fresh := points[:0]
+	for _, p := range points {
+		if _, ok := seen[p.Time.UnixNano()]; ok {
+			result.Duplicates++
+			continue
+		}
+		seen[p.Time.UnixNano()] = struct{}{}
+		fresh = append(fresh, p)
+	}
+
+	if err := s.appendTier(sr, t, fresh); err != nil {
+		return result, err
+	}
+	result.Imported = len(fresh)
+	return result, nil
+}
+
+func importRow(record []string, columns map[string]int, loc *time.Location, comma rune) (Point, error) {
+	field := func(name string) (string, bool) {
+		i := columns[name]
+		if i < 0 || i >= len(record) || strings.TrimSpace(record[i]) == "" {
+			return "", false
+		}
+		return record[i], true
+	}
+
+	var p Point
+	s, ok := field("time")
+	if !ok {
+		return p, errors.New("no time")
+	}
+	t, err := parseImportTime(s, loc)
+	if err != nil {
+		return p, err
+	}
+	p.Time = t
+
+	values := map[string]*float64{
+		"open": &p.Open, "high": &p.High, "low": &p.Low, "close": &p.Price,
+		"volume": &p.Volume24H, "marketcap": &p.MarketCap,
+	}
+	for name, v := range values {
+		s, ok := field(name)
+		if !ok {
+			continue
+		}
+		if *v, err = parseImportNumber(s, comma); err != nil {
+			return p, fmt.Errorf("bad %s %q", name, s)
+		}
+	}
+	if p.Price <= 0 {
+		return p, fmt.Errorf("bad close price %v", p.Price)
+	}
+	if p.Open <= 0 {
+		p.Open = p.Price
+	}
+	if p.High <= 0 {
+		p.High = math.Max(p.Open, p.Price)
+	}
+	if p.Low <= 0 {
+		p.Low = math.Min(p.Open, p.Price)
+	}
+	if p.High < p.Low {
+		return p, fmt.Errorf("high %v is below low %v", p.High, p.Low)
+	}
+	return p, nil
+}
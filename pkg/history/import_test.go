@@ -0,0 +1,31 @@
+package history
+
+import "testing"
+
+func TestParseImportNumber(t *testing.T) {
+	for _, tc := range []struct {
+		s     string
+		comma rune
+		want  float64
+	}{
+		{"1234.56", ',', 1234.56},
+		{"1,234.56", ',', 1234.56},
+		{"$1,234,567", ',', 1234567},
+		{"1234,56", ';', 1234.56},
+		{"1.234,56", ';', 1234.56},
+		{"1.234.567,8", ';', 1234567.8},
+		{"€ 1 234,56", ';', 1234.56},
+		{"1.234,56", ',', 1234.56},
+		{"1.234.567", ';', 1234567},
+		{"0.5", ';', 0.5},
+		{"1'234.5", ',', 1234.5},
+	} {
+		got, err := parseImportNumber(tc.s, tc.comma)
+		if err != nil || got != tc.want {
+			t.Errorf("parseImportNumber(%q, %q) = %v, %v, want %v", tc.s, tc.comma, got, err, tc.want)
+		}
+	}
+	if _, err := parseImportNumber("n/a", ','); err == nil {
+		t.Error("expected an error for a word")
+	}
+}
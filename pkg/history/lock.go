@@ -0,0 +1,42 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// lockName is the file locked by the process that writes the store.
+const lockName = ".lock"
+
+// ErrLocked is returned by Lock when another process holds the store.
+var ErrLocked = errors.New("history is in use by another process")
+
+// Lock keeps other processes from locking the store until it is closed.
+// It fails with ErrLocked if another process holds the lock.
+func (s *Store) Lock() error {
+	f, err := os.OpenFile(filepath.Join(s.dir, lockName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return err
+	}
+	s.lock.Lock()
+	s.held = f
+	s.lock.Unlock()
+	return nil
+}
+
+// Close releases the lock of the store.
+func (s *Store) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.held == nil {
+		return nil
+	}
+	err := s.held.Close()
+	s.held = nil
+	return err
+}
@@ -0,0 +1,29 @@
+package history
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLock(t *testing.T) {
+	dir := t.TempDir()
+	gui, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gui.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	cli, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.Lock(); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected the store to be locked, got %v", err)
+	}
+	gui.Close()
+	if err := cli.Lock(); err != nil {
+		t.Fatalf("lock is not released: %v", err)
+	}
+	cli.Close()
+}
@@ -0,0 +1,204 @@
+package history
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// partition is an open partition file of a tier. A partially written trailing record is ignored.
+type partition struct {
+	*os.File
+	tier tier
+	n    int
+}
+
+func openPartition(path string, t tier, flag int) (*partition, error) {
+	f, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &partition{
+		File: f,
+		tier: t,
+		n:    int(info.Size() / recordSize),
+	}, nil
+}
+
+// slice reads records [lo, hi).
+func (p *partition) slice(lo, hi int) ([]Point, error) {
+	if lo >= hi {
+		return nil, nil
+	}
+	b := make([]byte, (hi-lo)*recordSize)
+	if _, err := p.ReadAt(b, int64(lo)*recordSize); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return p.tier.decode(b), nil
+}
+
+func (p *partition) time(i int) (time.Time, error) {
+	var b [8]byte
+	if _, err := p.ReadAt(b[:], int64(i)*recordSize); err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, int64(binary.LittleEndian.Uint64(b[:]))), nil
+}
+
+// search returns the index of the first record after t, or at t if inclusive.
+func (p *partition) search(t time.Time, inclusive bool) (int, error) {
+	var err error
+	i := sort.Search(p.n, func(i int) bool {
+		ti, e := p.time(i)
+		if e != nil {
+			err = e
+			return true
+		}
+		return ti.After(t) || inclusive && ti.Equal(t)
+	})
+	return i, err
+}
+
+// appendPartition writes points sorted by time to a partition. Points later than stored ones are appended,
+// otherwise the partition is merged and rewritten. Points at stored times are merged into the stored ones.
+func appendPartition(path string, t tier, points []Point) error {
+	p, err := openPartition(path, t, os.O_RDWR|os.O_CREATE)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	var last time.Time
+	if p.n > 0 {
+		if last, err = p.time(p.n - 1); err != nil {
+			return err
+		}
+	}
+	// Providers report the same time until a quote changes
+	for len(points) > 0 && p.n > 0 && t.step == 0 && points[0].Time.Equal(last) {
+		points = points[1:]
+	}
+	if len(points) == 0 {
+		return nil
+	}
+	if p.n == 0 || points[0].Time.After(last) {
+		points = t.merge(points)
+		_, err := p.WriteAt(t.encode(points), int64(p.n)*recordSize)
+		return err
+	}
+
+	stored, err := p.slice(0, p.n)
+	if err != nil {
+		return err
+	}
+	p.Close()
+	return writePartition(path, t, append(stored, points...))
+}
+
+// writePartition replaces a partition with points, removing it if there are none.
+func writePartition(path string, t tier, points []Point) error {
+	sortPoints(points)
+	points = t.merge(points)
+	if len(points) == 0 {
+		err := os.Remove(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, t.encode(points), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// merge combines points sorted by time that are at the same time. The first raw point is kept,
+// rollups are combined.
+func (t tier) merge(points []Point) []Point {
+	ret := points[:0]
+	for i, p := range points {
+		if i > 0 && p.Time.Equal(ret[len(ret)-1].Time) {
+			if t.step > 0 {
+				ret[len(ret)-1] = ret[len(ret)-1].combine(p)
+			}
+			continue
+		}
+		ret = append(ret, p)
+	}
+	return ret
+}
+
+func readPartition(path string, t tier) ([]Point, error) {
+	p, err := openPartition(path, t, os.O_RDONLY)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+	return p.slice(0, p.n)
+}
+
+func readRange(path string, t tier, from, to time.Time) ([]Point, error) {
+	p, err := openPartition(path, t, os.O_RDONLY)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+
+	lo, err := p.search(from, true)
+	if err != nil {
+		return nil, err
+	}
+	hi, err := p.search(to, false)
+	if err != nil {
+		return nil, err
+	}
+	return p.slice(lo, hi)
+}
+
+// readLast reads up to n latest points before a time, unless it is zero.
+func readLast(path string, t tier, before time.Time, n int) ([]Point, error) {
+	p, err := openPartition(path, t, os.O_RDONLY)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+
+	hi := p.n
+	if !before.IsZero() {
+		if hi, err = p.search(before, true); err != nil {
+			return nil, err
+		}
+	}
+	lo := hi - n
+	if lo < 0 {
+		lo = 0
+	}
+	return p.slice(lo, hi)
+}
+
+func readAt(path string, t tier, at time.Time) (Point, bool, error) {
+	p, err := openPartition(path, t, os.O_RDONLY)
+	if err != nil {
+		return Point{}, false, err
+	}
+	defer p.Close()
+
+	i, err := p.search(at, false)
+	if err != nil || i == 0 {
+		return Point{}, false, err
+	}
+	points, err := p.slice(i-1, i)
+	if err != nil {
+		return Point{}, false, err
+	}
+	return points[0], true, nil
+}
@@ -0,0 +1,146 @@
+package history
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+)
+
+const (
+	// recordValues is the number of values stored in a record after the time in nanoseconds
+	recordValues = 14
+	// recordSize is the size of an encoded Point
+	recordSize = 8 * (recordValues + 1)
+)
+
+// Point is a quote stored in a series. Rollups are candles that start at Time and close with Price,
+// raw quotes have Open, High and Low equal to Price.
+type Point struct {
+	Time             time.Time
+	Open             float64
+	High             float64
+	Low              float64
+	Price            float64
+	Volume24H        float64
+	Volume7D         float64
+	Volume30D        float64
+	Volume24Hbase    float64
+	Volume24Hquote   float64
+	MarketCap        float64
+	PercentChange1H  float64
+	PercentChange24H float64
+	PercentChange7D  float64
+	PercentChange30D float64
+}
+
+// FromQuote converts a quote to a Point at the time the provider last updated it, or now if unknown.
+func FromQuote(q crypto.Quote) Point {
+	t := q.LastUpdated
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return Point{
+		Time:             t,
+		Open:             q.Price,
+		High:             q.Price,
+		Low:              q.Price,
+		Price:            q.Price,
+		Volume24H:        q.Volume24H,
+		Volume7D:         q.Volume7D,
+		Volume30D:        q.Volume30D,
+		Volume24Hbase:    q.Volume24Hbase,
+		Volume24Hquote:   q.Volume24Hquote,
+		MarketCap:        q.MarketCap,
+		PercentChange1H:  q.PercentChange1H,
+		PercentChange24H: q.PercentChange24H,
+		PercentChange7D:  q.PercentChange7D,
+		PercentChange30D: q.PercentChange30D,
+	}
+}
+
+func sortPoints(points []Point) {
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+}
+
+// combine merges p with a later point o into one candle.
+func (p Point) combine(o Point) Point {
+	ret := o
+	ret.Time = p.Time
+	ret.Open = p.Open
+	ret.High = math.Max(p.High, o.High)
+	ret.Low = math.Min(p.Low, o.Low)
+	return ret
+}
+
+// rollup aggregates points sorted by time into candles of step.
+func rollup(points []Point, step time.Duration) []Point {
+	var ret []Point
+	for _, p := range points {
+		start := p.Time.Truncate(step)
+		if n := len(ret); n > 0 && ret[n-1].Time.Equal(start) {
+			ret[n-1] = ret[n-1].combine(p)
+			continue
+		}
+		p.Time = start
+		ret = append(ret, p)
+	}
+	return ret
+}
+
+// values lists the stored values of a point.
+func (p Point) values() []float64 {
+	return []float64{
+		p.Price, p.Volume24H, p.Volume7D, p.Volume30D, p.Volume24Hbase, p.Volume24Hquote, p.MarketCap,
+		p.PercentChange1H, p.PercentChange24H, p.PercentChange7D, p.PercentChange30D, p.Open, p.High, p.Low,
+	}
+}
+
+func (t tier) marshal(p Point, b []byte) {
+	binary.LittleEndian.PutUint64(b, uint64(p.Time.UnixNano()))
+	for i, v := range p.values() {
+		binary.LittleEndian.PutUint64(b[8*(i+1):], math.Float64bits(v))
+	}
+}
+
+func (t tier) unmarshal(b []byte) Point {
+	var v [recordValues]float64
+	for i := range v {
+		v[i] = math.Float64frombits(binary.LittleEndian.Uint64(b[8*(i+1):]))
+	}
+	return Point{
+		Time:             time.Unix(0, int64(binary.LittleEndian.Uint64(b))),
+		Price:            v[0],
+		Volume24H:        v[1],
+		Volume7D:         v[2],
+		Volume30D:        v[3],
+		Volume24Hbase:    v[4],
+		Volume24Hquote:   v[5],
+		MarketCap:        v[6],
+		PercentChange1H:  v[7],
+		PercentChange24H: v[8],
+		PercentChange7D:  v[9],
+		PercentChange30D: v[10],
+		Open:             v[11],
+		High:             v[12],
+		Low:              v[13],
+	}
+}
+
+func (t tier) encode(points []Point) []byte {
+	b := make([]byte, len(points)*recordSize)
+	for i, p := range points {
+		t.marshal(p, b[i*recordSize:])
+	}
+	return b
+}
+
+func (t tier) decode(b []byte) []Point {
+	ret := make([]Point, len(b)/recordSize)
+	for i := range ret {
+		ret[i] = t.unmarshal(b[i*recordSize:])
+	}
+	return ret
+}
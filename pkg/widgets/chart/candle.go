@@ -0,0 +1,70 @@
+package chart
+
+import (
+	"sort"
+	"time"
+
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+)
+
+// Candle is a price bar drawn by the chart.
+type Candle struct {
+	Time   time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+}
+
+// FromOhlcv converts bars quoted in currency to candles ordered by time. Bars without a quote in currency are skipped.
+func FromOhlcv(currency string, data []crypto.Ohlcv) []Candle {
+	ret := make([]Candle, 0, len(data))
+	for _, o := range data {
+		q, ok := o.Quote[currency]
+		if !ok {
+			continue
+		}
+		t := o.TimeOpen
+		if t.IsZero() {
+			t = q.Timestamp
+		}
+		ret = append(ret, Candle{
+			Time:   t,
+			Open:   q.Open,
+			High:   q.High,
+			Low:    q.Low,
+			Close:  q.Close,
+			Volume: q.Volume,
+		})
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].Time.Before(ret[j].Time) })
+	return ret
+}
+
+// closeOnly reports whether candles carry nothing but close prices, which are drawn as a line.
+func closeOnly(candles []Candle) bool {
+	for _, c := range candles {
+		if c.Open != 0 && (c.Open != c.Close || c.High != c.Close || c.Low != c.Close) {
+			return false
+		}
+	}
+	return true
+}
+
+// bounds returns the range of c, missing open, high and low are taken from close.
+func (c Candle) bounds() (low, high float64) {
+	low, high = c.Close, c.Close
+	for _, v := range []float64{c.Open, c.High, c.Low} {
+		if v == 0 {
+			continue
+		}
+		if v < low {
+			low = v
+		}
+		if v > high {
+			high = v
+		}
+	}
+	return
+}
@@ -0,0 +1,207 @@
+package chart
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+)
+
+const (
+	// minSpan is the fewest candles the chart zooms in to
+	minSpan = 5
+	// defaultSpan is the number of latest candles shown when data is set
+	defaultSpan = 120
+	// zoomSpeed is the zoom factor per unit of scroll
+	zoomSpeed = 0.99
+)
+
+// DefaultIntervals are the intervals offered when none are set.
+var DefaultIntervals = []time.Duration{time.Hour, time.Hour * 4, time.Hour * 24, time.Hour * 24 * 7}
+
+// Chart draws candlesticks and volume bars. Prices are drawn as a line when candles only have close prices.
+// The mouse wheel zooms, dragging pans and hovering shows the values of the candle under the crosshair.
+type Chart struct {
+	widget.BaseWidget
+	sync.Mutex
+
+	candles   []Candle
+	intervals []time.Duration
+	interval  time.Duration
+
+	// end is the index past the last visible candle and span is the number of visible candles
+	end  float64
+	span float64
+
+	// cursor is relative to the plot which starts at plotTop below the interval selector
+	cursor   fyne.Position
+	hovering bool
+	plotTop  float32
+
+	onInterval func(time.Duration)
+}
+
+// New creates a chart that calls onInterval when the user selects another interval.
+func New(onInterval func(time.Duration)) *Chart {
+	ret := &Chart{
+		intervals:  DefaultIntervals,
+		interval:   DefaultIntervals[0],
+		onInterval: onInterval,
+	}
+	ret.ExtendBaseWidget(ret)
+	return ret
+}
+
+// MinSize returns the size that this widget should not shrink below.
+//
+// Implements: fyne.Widget
+func (w *Chart) MinSize() fyne.Size {
+	w.ExtendBaseWidget(w)
+	return w.BaseWidget.MinSize()
+}
+
+// SetOhlcv shows bars quoted in currency.
+func (w *Chart) SetOhlcv(currency string, data []crypto.Ohlcv) {
+	w.SetCandles(FromOhlcv(currency, data))
+}
+
+// SetCandles shows candles ordered by time and resets zoom to the latest ones.
+func (w *Chart) SetCandles(candles []Candle) {
+	w.Lock()
+	w.candles = candles
+	w.end = float64(len(candles))
+	w.span = math.Min(defaultSpan, w.end)
+	w.Unlock()
+	w.Refresh()
+}
+
+// SetIntervals sets the intervals the user can select.
+func (w *Chart) SetIntervals(intervals ...time.Duration) {
+	w.Lock()
+	w.intervals = intervals
+	w.Unlock()
+	w.Refresh()
+}
+
+// SetInterval selects the interval of the shown candles without notifying.
+func (w *Chart) SetInterval(interval time.Duration) {
+	w.Lock()
+	w.interval = interval
+	w.Unlock()
+	w.Refresh()
+}
+
+// Interval returns the selected interval.
+func (w *Chart) Interval() time.Duration {
+	w.Lock()
+	defer w.Unlock()
+	return w.interval
+}
+
+func (w *Chart) selectInterval(interval time.Duration) {
+	w.Lock()
+	changed := interval != w.interval
+	w.interval = interval
+	w.Unlock()
+	if changed && w.onInterval != nil {
+		w.onInterval(interval)
+	}
+}
+
+// visible returns the visible candles and the fractional index of the first one.
+func (w *Chart) visible() ([]Candle, float64) {
+	w.Lock()
+	defer w.Unlock()
+	if len(w.candles) == 0 || w.span <= 0 {
+		return nil, 0
+	}
+	start := w.end - w.span
+	first := int(math.Max(0, math.Floor(start)))
+	last := int(math.Min(float64(len(w.candles)), math.Ceil(w.end)))
+	return w.candles[first:last], start - float64(first)
+}
+
+// clampLocked keeps the view within the data.
+func (w *Chart) clampLocked() {
+	n := float64(len(w.candles))
+	w.span = math.Max(math.Min(w.span, n), math.Min(minSpan, n))
+	w.end = math.Max(math.Min(w.end, n), w.span)
+}
+
+// Scrolled zooms around the mouse pointer.
+//
+// Implements: fyne.Scrollable
+func (w *Chart) Scrolled(e *fyne.ScrollEvent) {
+	width := w.plotSize().Width
+	if width <= 0 {
+		return
+	}
+	w.Lock()
+	anchor := float64(e.Position.X / width)
+	at := w.end - w.span + anchor*w.span
+	w.span *= math.Pow(zoomSpeed, float64(e.Scrolled.DY))
+	w.clampLocked()
+	w.end = at + (1-anchor)*w.span
+	w.clampLocked()
+	w.Unlock()
+	w.Refresh()
+}
+
+// Dragged pans the chart.
+//
+// Implements: fyne.Draggable
+func (w *Chart) Dragged(e *fyne.DragEvent) {
+	width := w.plotSize().Width
+	if width <= 0 {
+		return
+	}
+	w.Lock()
+	w.end -= float64(e.Dragged.DX/width) * w.span
+	w.clampLocked()
+	w.cursor = e.Position.Subtract(fyne.NewPos(0, w.plotTop))
+	w.Unlock()
+	w.Refresh()
+}
+
+// DragEnd implements fyne.Draggable.
+func (w *Chart) DragEnd() {}
+
+// MouseIn shows the crosshair.
+//
+// Implements: desktop.Hoverable
+func (w *Chart) MouseIn(e *desktop.MouseEvent) {
+	w.MouseMoved(e)
+}
+
+// MouseMoved moves the crosshair.
+//
+// Implements: desktop.Hoverable
+func (w *Chart) MouseMoved(e *desktop.MouseEvent) {
+	w.Lock()
+	w.cursor = e.Position.Subtract(fyne.NewPos(0, w.plotTop))
+	w.hovering = true
+	w.Unlock()
+	w.Refresh()
+}
+
+// MouseOut hides the crosshair.
+//
+// Implements: desktop.Hoverable
+func (w *Chart) MouseOut() {
+	w.Lock()
+	w.hovering = false
+	w.Unlock()
+	w.Refresh()
+}
+
+// plotSize is the size of the area below the interval selector.
+func (w *Chart) plotSize() fyne.Size {
+	w.Lock()
+	top := w.plotTop
+	w.Unlock()
+	return w.Size().Subtract(fyne.NewSize(0, top))
+}
@@ -0,0 +1,106 @@
+package chart
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/test"
+	"github.com/itohio/CoinWatcher/pkg/crypto"
+)
+
+// testCandles are daily candles going up and down with a volume spike every ten days.
+func testCandles(n int, line bool) []Candle {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ret := make([]Candle, n)
+	for i := range ret {
+		open := 100 + 20*math.Sin(float64(i)/5)
+		close := 100 + 20*math.Sin(float64(i+1)/5)
+		c := Candle{
+			Time:   start.Add(time.Hour * 24 * time.Duration(i)),
+			Open:   open,
+			High:   math.Max(open, close) + 3,
+			Low:    math.Min(open, close) - 3,
+			Close:  close,
+			Volume: 1000 + 500*float64(i%10/9),
+		}
+		if line {
+			c = Candle{Time: c.Time, Close: c.Close}
+		}
+		ret[i] = c
+	}
+	return ret
+}
+
+func newTestChart(t *testing.T, candles []Candle) (*Chart, fyne.Window) {
+	test.NewApp()
+	c := New(nil)
+	c.SetInterval(time.Hour * 24)
+	c.SetCandles(candles)
+	w := test.NewWindow(c)
+	w.Resize(fyne.NewSize(400, 300))
+	t.Cleanup(w.Close)
+	return c, w
+}
+
+func TestChartCandles(t *testing.T) {
+	_, w := newTestChart(t, testCandles(40, false))
+	test.AssertImageMatches(t, "candles.png", w.Canvas().Capture())
+}
+
+func TestChartLine(t *testing.T) {
+	_, w := newTestChart(t, testCandles(40, true))
+	test.AssertImageMatches(t, "line.png", w.Canvas().Capture())
+}
+
+func TestChartEmpty(t *testing.T) {
+	_, w := newTestChart(t, nil)
+	test.AssertImageMatches(t, "empty.png", w.Canvas().Capture())
+}
+
+func TestChartHover(t *testing.T) {
+	c, w := newTestChart(t, testCandles(40, false))
+	c.MouseIn(&desktop.MouseEvent{PointEvent: fyne.PointEvent{Position: fyne.NewPos(200, 150)}})
+	test.AssertImageMatches(t, "hover.png", w.Canvas().Capture())
+}
+
+func TestChartZoom(t *testing.T) {
+	c, _ := newTestChart(t, testCandles(200, false))
+	if candles, _ := c.visible(); len(candles) != defaultSpan {
+		t.Fatalf("expected the latest %d candles, got %d", defaultSpan, len(candles))
+	}
+
+	c.Scrolled(&fyne.ScrollEvent{PointEvent: fyne.PointEvent{Position: fyne.NewPos(200, 150)}, Scrolled: fyne.NewDelta(0, 100)})
+	candles, _ := c.visible()
+	if len(candles) >= defaultSpan || len(candles) < minSpan {
+		t.Fatalf("zooming in shows %d candles", len(candles))
+	}
+	if last := candles[len(candles)-1].Time; !last.Before(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Hour * 24 * 199)) {
+		t.Fatal("zooming around the middle kept the latest candle in view")
+	}
+
+	c.Dragged(&fyne.DragEvent{Dragged: fyne.NewDelta(-10000, 0)})
+	if candles, _ := c.visible(); candles[len(candles)-1].Time != time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Hour*24*199) {
+		t.Fatal("panning past the end does not stop at the latest candle")
+	}
+}
+
+func TestFromOhlcv(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	candles := FromOhlcv("USD", []crypto.Ohlcv{
+		{TimeOpen: t0.Add(time.Hour), Quote: map[string]crypto.OhlcvQuote{"USD": {Open: 2, High: 3, Low: 1, Close: 2.5, Volume: 10}}},
+		{TimeOpen: t0, Quote: map[string]crypto.OhlcvQuote{"USD": {Open: 1, High: 2, Low: 0.5, Close: 2, Volume: 5}}},
+		{TimeOpen: t0.Add(time.Hour * 2), Quote: map[string]crypto.OhlcvQuote{"EUR": {Close: 2}}},
+	})
+	if len(candles) != 2 || !candles[0].Time.Equal(t0) || candles[1].Close != 2.5 || candles[1].Volume != 10 {
+		t.Fatalf("unexpected candles %+v", candles)
+	}
+	if closeOnly(candles) {
+		t.Fatal("candles with open, high and low are drawn as a line")
+	}
+	if !closeOnly([]Candle{{Close: 1}, {Open: 2, High: 2, Low: 2, Close: 2}}) {
+		t.Fatal("close prices are not drawn as a line")
+	}
+}
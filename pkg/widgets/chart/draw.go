@@ -0,0 +1,145 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/itohio/CoinWatcher/pkg/widgets/internal/raster"
+)
+
+const (
+	// volumeHeight is the part of the plot below prices used for volume bars
+	volumeHeight = 0.2
+	// pricePadding is the margin above and below visible prices
+	pricePadding = 0.05
+	// bodyWidth is the part of a candle slot filled by its body
+	bodyWidth = 0.7
+)
+
+var (
+	upColor     = color.NRGBA{0, 255, 0, 255}
+	downColor   = color.NRGBA{255, 0, 0, 255}
+	upVolume    = color.NRGBA{0, 255, 0, 96}
+	downVolume  = color.NRGBA{255, 0, 0, 96}
+	lineColor   = color.NRGBA{64, 160, 255, 255}
+	volumeColor = color.NRGBA{64, 160, 255, 96}
+)
+
+// view maps candles onto a plot. Candle i is centered at (i - offset + 0.5) slots from the left.
+type view struct {
+	candles []Candle
+	offset  float64
+	span    float64
+	line    bool
+
+	low, high float64
+	volume    float64
+}
+
+func newView(candles []Candle, offset, span float64, line bool) view {
+	v := view{candles: candles, offset: offset, span: span, line: line}
+	v.low, v.high = math.Inf(1), math.Inf(-1)
+	for _, c := range candles {
+		low, high := c.bounds()
+		if line {
+			low, high = c.Close, c.Close
+		}
+		v.low = math.Min(v.low, low)
+		v.high = math.Max(v.high, high)
+		v.volume = math.Max(v.volume, c.Volume)
+	}
+	pad := (v.high - v.low) * pricePadding
+	if pad == 0 {
+		pad = math.Abs(v.high) * pricePadding
+	}
+	v.low -= pad
+	v.high += pad
+	return v
+}
+
+// x returns the horizontal center of candle i as a fraction of the plot width.
+func (v view) x(i int) float64 {
+	return (float64(i) - v.offset + 0.5) / v.span
+}
+
+// index returns the candle under x given as a fraction of the plot width, or -1.
+func (v view) index(x float64) int {
+	i := int(math.Floor(x*v.span + v.offset))
+	if i < 0 || i >= len(v.candles) {
+		return -1
+	}
+	return i
+}
+
+// y returns the height of price as a fraction of the plot height, measured from the top.
+func (v view) y(price float64) float64 {
+	if v.high == v.low {
+		return (1 - volumeHeight) / 2
+	}
+	return (v.high - price) / (v.high - v.low) * (1 - volumeHeight)
+}
+
+// price returns the price at y given as a fraction of the plot height.
+func (v view) price(y float64) float64 {
+	return v.high - y/(1-volumeHeight)*(v.high-v.low)
+}
+
+// draw renders candles or the close price line with volume bars into an image of width by height pixels.
+func (v view) draw(width, height int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	if len(v.candles) == 0 || width < 2 || height < 2 {
+		return img
+	}
+	w, h := float64(width), float64(height)
+	slot := w / v.span
+
+	for i, c := range v.candles {
+		if v.volume <= 0 {
+			break
+		}
+		col := volumeColor
+		if !v.line {
+			col = downVolume
+			if c.Close >= c.Open {
+				col = upVolume
+			}
+		}
+		x := v.x(i) * w
+		top := h - c.Volume/v.volume*h*volumeHeight
+		fillRect(img, x-slot*bodyWidth/2, top, x+slot*bodyWidth/2, h, col)
+	}
+
+	if v.line {
+		for i := 1; i < len(v.candles); i++ {
+			raster.Line(img,
+				int(v.x(i-1)*w), int(v.y(v.candles[i-1].Close)*h),
+				int(v.x(i)*w), int(v.y(v.candles[i].Close)*h),
+				lineColor)
+		}
+		return img
+	}
+
+	for i, c := range v.candles {
+		col := downColor
+		if c.Close >= c.Open {
+			col = upColor
+		}
+		low, high := c.bounds()
+		x := v.x(i) * w
+		raster.Line(img, int(x), int(v.y(high)*h), int(x), int(v.y(low)*h), col)
+
+		top, bottom := v.y(math.Max(c.Open, c.Close))*h, v.y(math.Min(c.Open, c.Close))*h
+		fillRect(img, x-slot*bodyWidth/2, top, x+slot*bodyWidth/2, math.Max(bottom, top+1), col)
+	}
+	return img
+}
+
+func fillRect(img *image.NRGBA, x0, y0, x1, y1 float64, c color.Color) {
+	r := image.Rect(int(math.Round(x0)), int(math.Round(y0)), int(math.Round(x1)), int(math.Round(y1)))
+	if r.Dx() == 0 {
+		r.Max.X++
+	}
+	draw.Draw(img, r.Intersect(img.Bounds()), image.NewUniform(c), image.Point{}, draw.Over)
+}
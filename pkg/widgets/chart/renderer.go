@@ -0,0 +1,294 @@
+package chart
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	minWidth  = 240
+	minHeight = 160
+)
+
+type chartRenderer struct {
+	widget *Chart
+
+	interval *widget.Select
+	plot     *canvas.Raster
+	empty    *canvas.Text
+	high     *canvas.Text
+	low      *canvas.Text
+	from     *canvas.Text
+	to       *canvas.Text
+
+	vLine   *canvas.Line
+	hLine   *canvas.Line
+	price   *canvas.Text
+	tipBg   *canvas.Rectangle
+	tipText []*canvas.Text
+
+	view    view
+	objects []fyne.CanvasObject
+}
+
+func (w *Chart) CreateRenderer() fyne.WidgetRenderer {
+	w.ExtendBaseWidget(w)
+
+	r := &chartRenderer{
+		widget: w,
+		empty:  canvas.NewText("No data", theme.DisabledColor()),
+		high:   canvas.NewText("", theme.ForegroundColor()),
+		low:    canvas.NewText("", theme.ForegroundColor()),
+		from:   canvas.NewText("", theme.ForegroundColor()),
+		to:     canvas.NewText("", theme.ForegroundColor()),
+		vLine:  canvas.NewLine(theme.DisabledColor()),
+		hLine:  canvas.NewLine(theme.DisabledColor()),
+		price:  canvas.NewText("", theme.ForegroundColor()),
+		tipBg:  canvas.NewRectangle(theme.BackgroundColor()),
+	}
+	r.interval = widget.NewSelect(nil, func(s string) {
+		w.Lock()
+		intervals := w.intervals
+		w.Unlock()
+		for _, d := range intervals {
+			if formatInterval(d) == s {
+				w.selectInterval(d)
+			}
+		}
+	})
+	r.plot = canvas.NewRaster(func(width, height int) image.Image {
+		w.Lock()
+		v := r.view
+		w.Unlock()
+		return v.draw(width, height)
+	})
+	r.high.Alignment = fyne.TextAlignTrailing
+	r.low.Alignment = fyne.TextAlignTrailing
+	r.to.Alignment = fyne.TextAlignTrailing
+	r.price.Alignment = fyne.TextAlignTrailing
+	r.tipBg.StrokeColor = theme.DisabledColor()
+	r.tipBg.StrokeWidth = 1
+	for i := 0; i < 6; i++ {
+		r.tipText = append(r.tipText, canvas.NewText("", theme.ForegroundColor()))
+	}
+
+	r.objects = []fyne.CanvasObject{r.plot, r.empty, r.high, r.low, r.from, r.to, r.vLine, r.hLine, r.price, r.tipBg}
+	for _, t := range r.tipText {
+		r.objects = append(r.objects, t)
+	}
+	r.objects = append(r.objects, r.interval)
+
+	r.Refresh()
+	return r
+}
+
+func (r *chartRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(minWidth, minHeight+r.interval.MinSize().Height)
+}
+
+func (r *chartRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *chartRenderer) Destroy() {}
+
+func (r *chartRenderer) Refresh() {
+	w := r.widget
+	w.Lock()
+	var options []string
+	for _, d := range w.intervals {
+		options = append(options, formatInterval(d))
+	}
+	selected := formatInterval(w.interval)
+	line := closeOnly(w.candles)
+	span := w.span
+	w.Unlock()
+
+	candles, offset := w.visible()
+	v := newView(candles, offset, span, line)
+	w.Lock()
+	r.view = v
+	w.Unlock()
+
+	r.interval.Options = options
+	if r.interval.Selected != selected {
+		// The selector shows the interval set by the owner, selecting it does not call back
+		r.interval.Selected = selected
+	}
+	r.interval.Refresh()
+
+	r.applyTheme()
+	r.Layout(w.Size())
+	r.plot.Refresh()
+	canvas.Refresh(w)
+}
+
+func (r *chartRenderer) applyTheme() {
+	small := theme.TextSize() * 2.0 / 3.0
+	for _, t := range append([]*canvas.Text{r.high, r.low, r.from, r.to, r.price}, r.tipText...) {
+		t.TextSize = small
+		t.Color = theme.ForegroundColor()
+	}
+	r.empty.Color = theme.DisabledColor()
+	r.vLine.StrokeColor = theme.DisabledColor()
+	r.hLine.StrokeColor = theme.DisabledColor()
+	r.tipBg.FillColor = theme.BackgroundColor()
+	r.tipBg.StrokeColor = theme.DisabledColor()
+}
+
+func (r *chartRenderer) Layout(size fyne.Size) {
+	w := r.widget
+	header := r.interval.MinSize()
+	r.interval.Resize(header)
+	r.interval.Move(fyne.NewPos(0, 0))
+
+	top := header.Height + theme.Padding()
+	w.Lock()
+	w.plotTop = top
+	cursor, hovering := w.cursor, w.hovering
+	interval := w.interval
+	v := r.view
+	w.Unlock()
+
+	plot := fyne.NewSize(size.Width, size.Height-top)
+	r.plot.Resize(plot)
+	r.plot.Move(fyne.NewPos(0, top))
+
+	r.empty.Hidden = len(v.candles) > 0
+	r.empty.Resize(r.empty.MinSize())
+	r.empty.Move(fyne.NewPos((plot.Width-r.empty.MinSize().Width)/2, top+(plot.Height-r.empty.MinSize().Height)/2))
+	for _, t := range []*canvas.Text{r.high, r.low, r.from, r.to} {
+		t.Hidden = len(v.candles) == 0
+	}
+	r.hideCrosshair()
+	if len(v.candles) == 0 {
+		return
+	}
+
+	// Axis labels are drawn over the plot at the edges of the price and volume areas
+	r.high.Text = formatPrice(v.high)
+	r.low.Text = formatPrice(v.low)
+	r.from.Text = formatTime(v.candles[0].Time, interval)
+	r.to.Text = formatTime(v.candles[len(v.candles)-1].Time, interval)
+	priceBottom := top + plot.Height*(1-volumeHeight)
+	r.placeRight(r.high, size.Width, top)
+	r.placeRight(r.low, size.Width, priceBottom-r.low.MinSize().Height)
+	r.from.Resize(r.from.MinSize())
+	r.from.Move(fyne.NewPos(theme.Padding(), size.Height-r.from.MinSize().Height))
+	r.placeRight(r.to, size.Width, size.Height-r.to.MinSize().Height)
+
+	if !hovering || cursor.X < 0 || cursor.Y < 0 || cursor.X > plot.Width || cursor.Y > plot.Height {
+		return
+	}
+	i := v.index(float64(cursor.X / plot.Width))
+	if i < 0 {
+		return
+	}
+	x := float32(v.x(i)) * plot.Width
+	r.vLine.Position1 = fyne.NewPos(x, top)
+	r.vLine.Position2 = fyne.NewPos(x, size.Height)
+	r.hLine.Position1 = fyne.NewPos(0, top+cursor.Y)
+	r.hLine.Position2 = fyne.NewPos(size.Width, top+cursor.Y)
+	r.vLine.Hidden, r.hLine.Hidden = false, false
+	if cursor.Y < plot.Height*(1-volumeHeight) {
+		r.price.Text = formatPrice(v.price(float64(cursor.Y / plot.Height)))
+		r.price.Hidden = false
+		r.placeRight(r.price, size.Width, top+cursor.Y-r.price.MinSize().Height)
+	}
+	r.showTooltip(v.candles[i], v.line, interval, fyne.NewPos(x, top+cursor.Y), size)
+}
+
+func (r *chartRenderer) placeRight(t *canvas.Text, width, y float32) {
+	t.Resize(t.MinSize())
+	t.Move(fyne.NewPos(width-t.MinSize().Width-theme.Padding(), y))
+}
+
+func (r *chartRenderer) hideCrosshair() {
+	r.vLine.Hidden, r.hLine.Hidden, r.price.Hidden, r.tipBg.Hidden = true, true, true, true
+	for _, t := range r.tipText {
+		t.Hidden = true
+	}
+}
+
+// showTooltip lists values of c next to pos, flipped to stay inside size.
+func (r *chartRenderer) showTooltip(c Candle, line bool, interval time.Duration, pos fyne.Position, size fyne.Size) {
+	lines := []string{formatTime(c.Time, interval)}
+	if line {
+		lines = append(lines, "C: "+formatPrice(c.Close))
+	} else {
+		lines = append(lines, "O: "+formatPrice(c.Open), "H: "+formatPrice(c.High), "L: "+formatPrice(c.Low), "C: "+formatPrice(c.Close))
+	}
+	if c.Volume > 0 {
+		lines = append(lines, "V: "+formatPrice(c.Volume))
+	}
+
+	pad := theme.Padding()
+	var box fyne.Size
+	for i, t := range r.tipText {
+		if i >= len(lines) {
+			continue
+		}
+		t.Text = lines[i]
+		t.Hidden = false
+		t.Resize(t.MinSize())
+		box = box.Max(fyne.NewSize(t.MinSize().Width, box.Height))
+		box.Height += t.MinSize().Height
+	}
+	box = box.Add(fyne.NewSize(pad*2, pad*2))
+
+	at := pos.Add(fyne.NewPos(pad*2, pad*2))
+	if at.X+box.Width > size.Width {
+		at.X = pos.X - box.Width - pad*2
+	}
+	if at.Y+box.Height > size.Height {
+		at.Y = pos.Y - box.Height - pad*2
+	}
+	r.tipBg.Resize(box)
+	r.tipBg.Move(at)
+	r.tipBg.Hidden = false
+	y := at.Y + pad
+	for _, t := range r.tipText[:len(lines)] {
+		t.Move(fyne.NewPos(at.X+pad, y))
+		y += t.MinSize().Height
+	}
+}
+
+func formatInterval(d time.Duration) string {
+	switch {
+	case d >= time.Hour*24*7 && d%(time.Hour*24*7) == 0:
+		return fmt.Sprintf("%dw", d/(time.Hour*24*7))
+	case d >= time.Hour*24 && d%(time.Hour*24) == 0:
+		return fmt.Sprintf("%dd", d/(time.Hour*24))
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	return fmt.Sprintf("%dm", d/time.Minute)
+}
+
+// formatTime shows daily candles by their UTC date and shorter ones in local time.
+func formatTime(t time.Time, interval time.Duration) string {
+	if interval >= time.Hour*24 {
+		return t.UTC().Format("2006-01-02")
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func formatPrice(v float64) string {
+	switch a := math.Abs(v); {
+	case a >= 1e9:
+		return fmt.Sprintf("%0.2fB", v/1e9)
+	case a >= 1e6:
+		return fmt.Sprintf("%0.2fM", v/1e6)
+	case a >= 1e3:
+		return fmt.Sprintf("%0.2f", v)
+	}
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
@@ -14,12 +14,21 @@ var ErrBadData = errors.New("bad data")
 
 type CoinData struct {
 	crypto.Symbol
-	crypto.Quote
+	// Quotes holds the latest quote in each currency
+	Quotes map[string]crypto.Quote
+	// Currency is the primary display currency and Secondary is shown under it if set
+	Currency  string
+	Secondary string
+	// Sparkline holds recent prices in Currency, oldest first
+	Sparkline []float64
 }
 
-func NewSymbol(symbol crypto.Symbol) *CoinData {
+func NewSymbol(symbol crypto.Symbol, currency, secondary string) *CoinData {
 	return &CoinData{
-		Symbol: symbol,
+		Symbol:    symbol,
+		Quotes:    make(map[string]crypto.Quote),
+		Currency:  currency,
+		Secondary: secondary,
 	}
 }
 
@@ -69,24 +78,48 @@ func (w *CoinWidget) DataChanged() {
 		//w.icon.Resize(fyne.NewSize(32, 32))
 		w.icon.FillMode = canvas.ImageFillContain
 	}
+	quote := data.Quotes[data.Currency]
+	w.coin = data.Symbol
 	w.symbol = data.Symbol.Symbol
 	w.name = data.Symbol.Name
-	w.price = data.Price
-	w.volume = data.Volume24H
-	w.marketCap = data.MarketCap
-	w.pc1H = data.PercentChange1H
-	w.pc24H = data.PercentChange24H
-	w.pc7D = data.PercentChange7D
-	w.pc30D = data.PercentChange30D
+	w.price = quote.Price
+	w.volume = quote.Volume24H
+	w.marketCap = quote.MarketCap
+	w.pc1H = quote.PercentChange1H
+	w.pc24H = quote.PercentChange24H
+	w.pc7D = quote.PercentChange7D
+	w.pc30D = quote.PercentChange30D
+	w.secondary = data.Secondary
+	w.secondaryPrice = data.Quotes[data.Secondary].Price
+	w.updated = quote.LastUpdated
+	w.Lock()
+	w.spark = data.Sparkline
+	w.Unlock()
 	w.Refresh()
 }
 
-func (c *CoinData) UpdateQuote(q crypto.Quote) *CoinData {
-	if c.Symbol.Symbol != q.Symbol.Symbol {
+// UpdateQuote returns a copy of c holding q as the quote in currency.
+func (c *CoinData) UpdateQuote(currency string, q crypto.Quote) *CoinData {
+	if !c.Symbol.Is(q.Symbol) {
 		return nil
 	}
-	return &CoinData{
-		Symbol: q.Symbol,
-		Quote:  q,
+	ret := c.WithCurrencies(c.Currency, c.Secondary)
+	// Quotes of another provider do not rebind the coin
+	if c.Symbol.Provider == "" || c.Symbol.Provider == q.Symbol.Provider {
+		ret.Symbol = q.Symbol
+	}
+	ret.Quotes[currency] = q
+	return ret
+}
+
+// WithCurrencies returns a copy of c displaying currency and secondary.
+func (c *CoinData) WithCurrencies(currency, secondary string) *CoinData {
+	ret := NewSymbol(c.Symbol, currency, secondary)
+	for k, q := range c.Quotes {
+		ret.Quotes[k] = q
+	}
+	if currency == c.Currency {
+		ret.Sparkline = c.Sparkline
 	}
+	return ret
 }
@@ -2,12 +2,14 @@ package coin
 
 import (
 	"sync"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"github.com/itohio/CoinWatcher/pkg/crypto"
 )
 
 type CoinWidget struct {
@@ -16,6 +18,7 @@ type CoinWidget struct {
 
 	iconUrl   string
 	icon      *canvas.Image
+	coin      crypto.Symbol
 	symbol    string
 	name      string
 	price     float64
@@ -25,16 +28,23 @@ type CoinWidget struct {
 	pc7D      float64
 	pc30D     float64
 	marketCap float64
+	spark     []float64
 
-	data   binding.DataItem
-	onMenu func(string)
+	secondary      string
+	secondaryPrice float64
+	updated        time.Time
+	staleAfter     time.Duration
 
-	showStats bool
+	data   binding.DataItem
+	onMenu func(crypto.Symbol)
+	onTap  func(crypto.Symbol)
 }
 
-func New(onMenu func(string)) *CoinWidget {
+// New creates a coin row. onMenu is called by the delete button and onTap when the row is tapped.
+func New(onMenu, onTap func(crypto.Symbol)) *CoinWidget {
 	ret := &CoinWidget{
 		onMenu: onMenu,
+		onTap:  onTap,
 		icon:   canvas.NewImageFromResource(theme.FileImageIcon()),
 	}
 	ret.ExtendBaseWidget(ret)
@@ -50,7 +60,21 @@ func (w *CoinWidget) MinSize() fyne.Size {
 	return w.BaseWidget.MinSize()
 }
 
+// SetStaleAfter flags quotes older than d, zero disables the warning.
+func (w *CoinWidget) SetStaleAfter(d time.Duration) {
+	w.Lock()
+	w.staleAfter = d
+	w.Unlock()
+}
+
+func (w *CoinWidget) stale() bool {
+	w.Lock()
+	defer w.Unlock()
+	return w.staleAfter > 0 && !w.updated.IsZero() && time.Since(w.updated) > w.staleAfter
+}
+
 func (w *CoinWidget) Tapped(*fyne.PointEvent) {
-	w.showStats = !w.showStats
-	w.Refresh()
+	if w.onTap != nil && w.coin.Symbol != "" {
+		w.onTap(w.coin)
+	}
 }
@@ -3,6 +3,7 @@ package coin
 import (
 	"fmt"
 	"image/color"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -16,7 +17,9 @@ const iconSize = 24
 type coinRenderer struct {
 	symbol    *canvas.Text
 	name      *canvas.Text
+	age       *canvas.Text
 	price     *canvas.Text
+	secondary *canvas.Text
 	marketCap *canvas.Text
 	volume    *canvas.Text
 	pc1H      *canvas.Text
@@ -24,6 +27,7 @@ type coinRenderer struct {
 	pc7D      *canvas.Text
 	pc30D     *canvas.Text
 	icon      *canvas.Image
+	spark     *canvas.Raster
 
 	widget    *CoinWidget
 	container *fyne.Container
@@ -34,8 +38,11 @@ func (w *CoinWidget) CreateRenderer() fyne.WidgetRenderer {
 
 	symbol := canvas.NewText(w.symbol, theme.ForegroundColor())
 	name := canvas.NewText(w.name, theme.ForegroundColor())
+	age := canvas.NewText("", theme.ForegroundColor())
 	price := canvas.NewText("", theme.ForegroundColor())
 	price.Alignment = fyne.TextAlignTrailing
+	secondary := canvas.NewText("", theme.ForegroundColor())
+	secondary.Alignment = fyne.TextAlignTrailing
 
 	volume := canvas.NewText("", theme.ForegroundColor())
 	volume.Alignment = fyne.TextAlignTrailing
@@ -51,13 +58,16 @@ func (w *CoinWidget) CreateRenderer() fyne.WidgetRenderer {
 		widget:    w,
 		symbol:    symbol,
 		name:      name,
+		age:       age,
 		price:     price,
+		secondary: secondary,
 		volume:    volume,
 		marketCap: marketCap,
 		pc1H:      pc1H,
 		pc24H:     pc24H,
 		pc7D:      pc7D,
 		pc30D:     pc30D,
+		spark:     newSparkline(w),
 	}
 
 	ret.refreshNumbers()
@@ -68,8 +78,8 @@ func (w *CoinWidget) CreateRenderer() fyne.WidgetRenderer {
 
 func (r *coinRenderer) updateObjects() {
 	var icon fyne.CanvasObject = r.widget.icon
-	symbol := container.NewVBox(r.symbol, r.name)
-	price := container.NewVBox(r.price, r.volume, r.marketCap)
+	symbol := container.NewVBox(r.symbol, r.name, r.age)
+	price := container.NewVBox(r.price, r.secondary, r.volume, r.marketCap)
 
 	var objs []fyne.CanvasObject
 
@@ -79,7 +89,7 @@ func (r *coinRenderer) updateObjects() {
 	if r.widget.onMenu != nil {
 		btn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
 			if r.widget.onMenu != nil {
-				r.widget.onMenu(r.widget.symbol)
+				r.widget.onMenu(r.widget.coin)
 			}
 		})
 		btn.Importance = widget.LowImportance
@@ -92,7 +102,12 @@ func (r *coinRenderer) updateObjects() {
 	a := canvas.NewText("493.9999K", theme.ForegroundColor()).MinSize()
 	b := canvas.NewText("W: 5555.5", theme.ForegroundColor()).MinSize()
 
-	root := container.New(&table{sizes: []float32{-1, a.Width, b.Width}}, name, price, stats)
+	var root *fyne.Container
+	if r.widget.hasSparkline() {
+		root = container.New(&table{sizes: []float32{-1, a.Width, b.Width, sparklineWidth}}, name, price, stats, container.NewCenter(r.spark))
+	} else {
+		root = container.New(&table{sizes: []float32{-1, a.Width, b.Width}}, name, price, stats)
+	}
 	r.container = root
 
 	r.applyTheme()
@@ -131,10 +146,36 @@ func formatNumber(prefix string, n float64, accuracy int) string {
 	}
 }
 
+// formatAge describes how long ago t was.
+func formatAge(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	age := time.Since(t)
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", age/time.Minute)
+	case age < time.Hour*24:
+		return fmt.Sprintf("%dh ago", age/time.Hour)
+	default:
+		return fmt.Sprintf("%dd ago", age/(time.Hour*24))
+	}
+}
+
 func (r *coinRenderer) refreshNumbers() {
 	r.symbol.Text = r.widget.symbol
 	r.name.Text = r.widget.name
+	r.age.Text = formatAge(r.widget.updated)
+	if r.widget.stale() {
+		r.age.Text = "stale, " + r.age.Text
+	}
 	r.price.Text = formatNumber("", r.widget.price, 2)
+	r.secondary.Text = ""
+	if r.widget.secondary != "" {
+		r.secondary.Text = formatNumber("", r.widget.secondaryPrice, 2) + " " + r.widget.secondary
+	}
 	r.marketCap.Text = formatNumber("mc: ", r.widget.marketCap, 1)
 	r.volume.Text = formatNumber("V: ", r.widget.volume, 1)
 
@@ -148,6 +189,7 @@ func (r *coinRenderer) Refresh() {
 	r.refreshNumbers()
 	r.updateObjects()
 	r.container.Refresh()
+	r.spark.Refresh()
 
 	r.Layout(r.widget.Size())
 	canvas.Refresh(r.widget)
@@ -169,7 +211,12 @@ func (r *coinRenderer) applyTheme() {
 	r.name.TextSize = theme.TextSubHeadingSize()
 	r.name.Color = theme.ForegroundColor()
 
+	r.age.TextSize = theme.TextSize() * 2.0 / 3.0
+	r.age.Color = theme.ForegroundColor()
+
 	r.price.TextSize = theme.TextSize()
+	r.secondary.TextSize = theme.TextSize() * 2.0 / 3.0
+	r.secondary.Color = theme.ForegroundColor()
 	r.volume.TextSize = theme.TextSize() * 2.0 / 3.0
 	r.applyThemeChange(r.price, r.widget.pc1H)
 	r.applyThemeChange(r.volume, r.widget.pc24H)
@@ -184,6 +231,12 @@ func (r *coinRenderer) applyTheme() {
 	r.applyThemeChange(r.pc24H, r.widget.pc24H)
 	r.applyThemeChange(r.pc7D, r.widget.pc7D)
 	r.applyThemeChange(r.pc30D, r.widget.pc30D)
+
+	if r.widget.stale() {
+		r.age.Color = theme.ErrorColor()
+		r.price.Color = theme.DisabledColor()
+		r.secondary.Color = theme.DisabledColor()
+	}
 }
 
 func (r *coinRenderer) applyThemeChange(obj *canvas.Text, change float64) {
@@ -0,0 +1,72 @@
+package coin
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"github.com/itohio/CoinWatcher/pkg/widgets/internal/raster"
+)
+
+const (
+	sparklineWidth  = 64
+	sparklineHeight = 36
+)
+
+// newSparkline draws the price history of w scaled to fit, coloured by the direction of the change.
+func newSparkline(w *CoinWidget) *canvas.Raster {
+	ret := canvas.NewRaster(func(width, height int) image.Image {
+		w.Lock()
+		values := w.spark
+		w.Unlock()
+		return drawSparkline(values, width, height)
+	})
+	ret.SetMinSize(fyne.NewSize(sparklineWidth, sparklineHeight))
+	return ret
+}
+
+func (w *CoinWidget) hasSparkline() bool {
+	w.Lock()
+	defer w.Unlock()
+	return len(w.spark) > 1
+}
+
+func drawSparkline(values []float64, width, height int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	if len(values) < 2 || width < 2 || height < 2 {
+		return img
+	}
+
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	scale := float64(height-1) / (max - min)
+	if max == min {
+		scale = 0
+	}
+
+	c := color.NRGBA{0, 255, 0, 255}
+	if values[len(values)-1] < values[0] {
+		c = color.NRGBA{255, 0, 0, 255}
+	}
+
+	point := func(i int) (int, int) {
+		x := i * (width - 1) / (len(values) - 1)
+		y := height - 1 - int(math.Round((values[i]-min)*scale))
+		if scale == 0 {
+			y = height / 2
+		}
+		return x, y
+	}
+	x0, y0 := point(0)
+	for i := 1; i < len(values); i++ {
+		x1, y1 := point(i)
+		raster.Line(img, x0, y0, x1, y1, c)
+		x0, y0 = x1, y1
+	}
+	return img
+}